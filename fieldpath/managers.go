@@ -14,8 +14,13 @@ limitations under the License.
 package fieldpath
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
 )
 
 // APIVersion describes the version of an object or of a fieldset.
@@ -27,11 +32,98 @@ type VersionedSet interface {
 	Applied() bool
 }
 
+// ManagerMetadata is what a VersionedSet can record about its manager,
+// on top of its fields, their version and whether they were applied (see
+// NewVersionedSetWithMetadata).
+type ManagerMetadata struct {
+	// Time is when the fields of the manager, their version, whether
+	// they were applied, its deleted fields or its labels last changed,
+	// if known.
+	Time time.Time
+	// Subresource is the subresource through which the manager changed
+	// the object, if any.
+	Subresource string
+	// Labels are arbitrary labels. They must not be modified once
+	// given to NewVersionedSetWithMetadata.
+	Labels map[string]string
+
+	// Contested are the fields that the manager shares with other
+	// managers, and whose value one of them has changed since. They are
+	// part of the fields of the manager.
+	Contested *Set
+	// Deleted are the fields that the manager explicitly deleted in its
+	// last apply. They aren't part of the fields of the manager, since
+	// they are no longer in the object.
+	Deleted *Set
+}
+
+// ScopedManager returns the key of ManagedFields under which the fields
+// that manager changes through a subresource are owned. The key is
+// manager itself if there is no subresource, and otherwise encodes both,
+// so that the keys of different pairs are always different. Use
+// ParseScopedManager to get the pair back.
+func ScopedManager(manager, subresource string) string {
+	if subresource == "" {
+		if _, ok := parseScopedKey(manager); !ok {
+			return manager
+		}
+	}
+	// The fields are always sorted the same way, which makes the
+	// encoding canonical.
+	key, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(scopedKey{Manager: manager, Subresource: subresource})
+	if err != nil {
+		// Strings can always be encoded.
+		panic(err)
+	}
+	return string(key)
+}
+
+// ParseScopedManager returns the manager and the subresource of a key of
+// ManagedFields given by ScopedManager. Other keys are returned as the
+// manager, without a subresource.
+func ParseScopedManager(key string) (manager, subresource string) {
+	if k, ok := parseScopedKey(key); ok {
+		return k.Manager, k.Subresource
+	}
+	return key, ""
+}
+
+// scopedKey is the encoding of the keys of managers with a subresource.
+type scopedKey struct {
+	Manager     string `json:"manager"`
+	Subresource string `json:"subresource,omitempty"`
+}
+
+// parseScopedKey decodes key if it is a canonical scopedKey encoding.
+func parseScopedKey(key string) (scopedKey, bool) {
+	if !strings.HasPrefix(key, "{") {
+		return scopedKey{}, false
+	}
+	var k scopedKey
+	decoder := jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(strings.NewReader(key))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&k); err != nil || decoder.More() {
+		return scopedKey{}, false
+	}
+	if encoded, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(k); err != nil || !bytes.Equal(encoded, []byte(key)) {
+		return scopedKey{}, false
+	}
+	return k, true
+}
+
+// MetadataVersionedSet is a VersionedSet that carries the metadata of its
+// manager.
+type MetadataVersionedSet interface {
+	VersionedSet
+	Metadata() ManagerMetadata
+}
+
 // VersionedSet associates a version to a set.
 type versionedSet struct {
 	set        *Set
 	apiVersion APIVersion
 	applied    bool
+	metadata   ManagerMetadata
 }
 
 func NewVersionedSet(set *Set, apiVersion APIVersion, applied bool) VersionedSet {
@@ -42,6 +134,38 @@ func NewVersionedSet(set *Set, apiVersion APIVersion, applied bool) VersionedSet
 	}
 }
 
+// NewVersionedSetWithMetadata returns a VersionedSet carrying the given
+// metadata of its manager. Contested fields that aren't in set are
+// ignored.
+func NewVersionedSetWithMetadata(set *Set, apiVersion APIVersion, applied bool, metadata ManagerMetadata) VersionedSet {
+	if metadata.Contested != nil {
+		metadata.Contested = metadata.Contested.Intersection(set)
+	}
+	return versionedSet{
+		set:        set,
+		apiVersion: apiVersion,
+		applied:    applied,
+		metadata:   metadata,
+	}
+}
+
+// MetadataOf returns the metadata of a VersionedSet, which is empty
+// unless it is a MetadataVersionedSet. Its contested and deleted fields
+// are never nil.
+func MetadataOf(v VersionedSet) ManagerMetadata {
+	var metadata ManagerMetadata
+	if m, ok := v.(MetadataVersionedSet); ok {
+		metadata = m.Metadata()
+	}
+	if metadata.Contested == nil {
+		metadata.Contested = NewSet()
+	}
+	if metadata.Deleted == nil {
+		metadata.Deleted = NewSet()
+	}
+	return metadata
+}
+
 func (v versionedSet) Set() *Set {
 	return v.set
 }
@@ -54,12 +178,17 @@ func (v versionedSet) Applied() bool {
 	return v.applied
 }
 
+func (v versionedSet) Metadata() ManagerMetadata {
+	return v.metadata
+}
+
 // ManagedFields is a map from manager to VersionedSet (what they own in
 // what version).
 type ManagedFields map[string]VersionedSet
 
 // Equals returns true if the two managedfields are the same, false
-// otherwise.
+// otherwise. Only the contested and deleted fields of the metadata of
+// the managers are compared.
 func (lhs ManagedFields) Equals(rhs ManagedFields) bool {
 	if len(lhs) != len(rhs) {
 		return false
@@ -76,10 +205,113 @@ func (lhs ManagedFields) Equals(rhs ManagedFields) bool {
 		if !left.Set().Equals(right.Set()) {
 			return false
 		}
+		leftMetadata, rightMetadata := MetadataOf(left), MetadataOf(right)
+		if !leftMetadata.Contested.Equals(rightMetadata.Contested) || !leftMetadata.Deleted.Equals(rightMetadata.Deleted) {
+			return false
+		}
 	}
 	return true
 }
 
+// Contested returns the contested fields of each manager that has some.
+func (lhs ManagedFields) Contested() ManagedFields {
+	contested := ManagedFields{}
+	for manager, set := range lhs {
+		if c := MetadataOf(set).Contested; !c.Empty() {
+			contested[manager] = NewVersionedSet(c, set.APIVersion(), set.Applied())
+		}
+	}
+	return contested
+}
+
+// OwnersOf returns the sorted names of the managers that own the field
+// at p, a field it is under, or fields under it. p is at version, which
+// the sets of all managers must be at too: use ConvertManagedFields of
+// merge.Updater first otherwise.
+func (lhs ManagedFields) OwnersOf(p Path, version APIVersion) ([]string, error) {
+	if err := lhs.checkVersion(version); err != nil {
+		return nil, err
+	}
+	owners := []string{}
+	for manager, set := range lhs {
+		if owns(set.Set(), p) {
+			owners = append(owners, manager)
+		}
+	}
+	sort.Strings(owners)
+	return owners, nil
+}
+
+// checkVersion returns an error if the set of a manager isn't at version.
+func (lhs ManagedFields) checkVersion(version APIVersion) error {
+	for manager, set := range lhs {
+		if set.APIVersion() != version {
+			return fmt.Errorf("fields of %q are at version %v, not %v", manager, set.APIVersion(), version)
+		}
+	}
+	return nil
+}
+
+// owns returns true if s has p, one of its parents, or a path under it.
+func owns(s *Set, p Path) bool {
+	if len(p) == 0 {
+		return !s.Empty()
+	}
+	for i := range p {
+		if s.Has(p[:i+1]) {
+			return true
+		}
+	}
+	for _, pe := range p {
+		s = s.WithPrefix(pe)
+	}
+	return !s.Empty()
+}
+
+// OwnedBy returns the fields owned by manager at version, which are empty
+// if it doesn't manage anything. An error is returned if its fields are
+// at another version.
+func (lhs ManagedFields) OwnedBy(manager string, version APIVersion) (*Set, error) {
+	set, ok := lhs[manager]
+	if !ok {
+		return NewSet(), nil
+	}
+	if set.APIVersion() != version {
+		return nil, fmt.Errorf("fields of %q are at version %v, not %v", manager, set.APIVersion(), version)
+	}
+	return set.Set(), nil
+}
+
+// Unowned returns the fields of fields, usually those of a live object,
+// that no manager owns, either directly or through a field they are
+// under. fields are at version, which the sets of all managers must be
+// at too.
+func (lhs ManagedFields) Unowned(fields *Set, version APIVersion) (*Set, error) {
+	if err := lhs.checkVersion(version); err != nil {
+		return nil, err
+	}
+	unowned := fields
+	for _, set := range lhs {
+		unowned = unowned.RecursiveDifference(set.Set())
+	}
+	return unowned, nil
+}
+
+// Overlaps returns the fields at version that more than one manager owns.
+// The sets of all managers must be at version.
+func (lhs ManagedFields) Overlaps(version APIVersion) (*Set, error) {
+	if err := lhs.checkVersion(version); err != nil {
+		return nil, err
+	}
+	seen := NewSet()
+	overlaps := NewSet()
+	for _, set := range lhs {
+		overlaps = overlaps.Union(seen.Intersection(set.Set()))
+		seen = seen.Union(set.Set())
+	}
+	return overlaps, nil
+}
+
 // Copy the list, this is mostly a shallow copy.
 func (lhs ManagedFields) Copy() ManagedFields {
 	copy := ManagedFields{}
@@ -92,7 +324,9 @@ func (lhs ManagedFields) Copy() ManagedFields {
 // Difference returns a symmetric difference between two Managers. If a
 // given user's entry has version X in lhs and version Y in rhs, then
 // the return value for that user will be from rhs. If the difference for
-// a user is an empty set, that user will not be inserted in the map.
+// a user is an empty set, that user will not be inserted in the map. The
+// metadata of the managers in the difference is the one of rhs, when
+// they are in rhs.
 func (lhs ManagedFields) Difference(rhs ManagedFields) ManagedFields {
 	diff := ManagedFields{}
 
@@ -115,7 +349,11 @@ func (lhs ManagedFields) Difference(rhs ManagedFields) ManagedFields {
 
 		newSet := left.Set().Difference(right.Set()).Union(right.Set().Difference(left.Set()))
 		if !newSet.Empty() {
-			diff[manager] = NewVersionedSet(newSet, right.APIVersion(), false)
+			var metadata ManagerMetadata
+			if m, ok := right.(MetadataVersionedSet); ok {
+				metadata = m.Metadata()
+			}
+			diff[manager] = NewVersionedSetWithMetadata(newSet, right.APIVersion(), false, metadata)
 		}
 	}
 
@@ -139,6 +377,16 @@ func (lhs ManagedFields) String() string {
 		fmt.Fprintf(&s, "- Applied: %v\n", v.Applied())
 		fmt.Fprintf(&s, "- APIVersion: %v\n", v.APIVersion())
 		fmt.Fprintf(&s, "- Set: %v\n", v.Set())
+		m := MetadataOf(v)
+		if !m.Contested.Empty() {
+			fmt.Fprintf(&s, "- Contested: %v\n", m.Contested)
+		}
+		if !m.Deleted.Empty() {
+			fmt.Fprintf(&s, "- Deleted: %v\n", m.Deleted)
+		}
+		if !m.Time.IsZero() || m.Subresource != "" || len(m.Labels) != 0 {
+			fmt.Fprintf(&s, "- Time: %v, Subresource: %q, Labels: %v\n", m.Time, m.Subresource, m.Labels)
+		}
 	}
 	return s.String()
 }
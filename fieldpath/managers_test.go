@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
 )
@@ -270,6 +271,44 @@ func TestManagersDifference(t *testing.T) {
 			},
 			equal: false,
 		},
+		{
+			name: "Contested difference",
+			lhs: fieldpath.ManagedFields{
+				"one": fieldpath.NewVersionedSetWithMetadata(
+					_NS(_P("numeric"), _P("string")),
+					"v1",
+					false,
+					fieldpath.ManagerMetadata{Contested: _NS(_P("string"))},
+				),
+			},
+			rhs: fieldpath.ManagedFields{
+				"one": fieldpath.NewVersionedSet(
+					_NS(_P("numeric"), _P("string")),
+					"v1",
+					false,
+				),
+			},
+			equal: false,
+		},
+		{
+			name: "Contested outside of set",
+			lhs: fieldpath.ManagedFields{
+				"one": fieldpath.NewVersionedSetWithMetadata(
+					_NS(_P("numeric"), _P("string")),
+					"v1",
+					false,
+					fieldpath.ManagerMetadata{Contested: _NS(_P("bool"))},
+				),
+			},
+			rhs: fieldpath.ManagedFields{
+				"one": fieldpath.NewVersionedSet(
+					_NS(_P("numeric"), _P("string")),
+					"v1",
+					false,
+				),
+			},
+			equal: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -284,3 +323,179 @@ func TestManagersDifference(t *testing.T) {
 		})
 	}
 }
+
+func TestManagersContested(t *testing.T) {
+	managers := fieldpath.ManagedFields{
+		"one": fieldpath.NewVersionedSetWithMetadata(
+			_NS(_P("numeric"), _P("string")),
+			"v1",
+			true,
+			fieldpath.ManagerMetadata{Contested: _NS(_P("string"))},
+		),
+		"two": fieldpath.NewVersionedSet(
+			_NS(_P("bool")),
+			"v1",
+			false,
+		),
+	}
+	want := fieldpath.ManagedFields{
+		"one": fieldpath.NewVersionedSet(
+			_NS(_P("string")),
+			"v1",
+			true,
+		),
+	}
+	if got := managers.Contested(); !got.Equals(want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestManagersOwnership(t *testing.T) {
+	managers := fieldpath.ManagedFields{
+		"one": fieldpath.NewVersionedSet(
+			_NS(_P("numeric"), _P("obj", "string")),
+			"v1",
+			true,
+		),
+		"two": fieldpath.NewVersionedSet(
+			_NS(_P("numeric"), _P("bool")),
+			"v1",
+			false,
+		),
+	}
+
+	for _, test := range []struct {
+		path   fieldpath.Path
+		owners []string
+	}{
+		{path: _P("numeric"), owners: []string{"one", "two"}},
+		{path: _P("obj"), owners: []string{"one"}},
+		{path: _P("obj", "string"), owners: []string{"one"}},
+		{path: _P("obj", "bool"), owners: []string{}},
+		{path: _P("string"), owners: []string{}},
+	} {
+		if got, err := managers.OwnersOf(test.path, "v1"); err != nil {
+			t.Errorf("failed to get owners of %v: %v", test.path, err)
+		} else if !reflect.DeepEqual(got, test.owners) {
+			t.Errorf("expected owners of %v to be %v, got %v", test.path, test.owners, got)
+		}
+	}
+
+	if got, err := managers.OwnedBy("two", "v1"); err != nil {
+		t.Errorf("failed to get fields of two: %v", err)
+	} else if want := _NS(_P("numeric"), _P("bool")); !got.Equals(want) {
+		t.Errorf("expected fields of two to be %v, got %v", want, got)
+	}
+	if got, err := managers.OwnedBy("three", "v1"); err != nil || !got.Empty() {
+		t.Errorf("expected three not to own anything, got %v, %v", got, err)
+	}
+	live := _NS(_P("numeric"), _P("bool"), _P("string"), _P("obj", "string"), _P("obj", "bool"))
+	if got, err := managers.Unowned(live, "v1"); err != nil {
+		t.Errorf("failed to get unowned fields: %v", err)
+	} else if want := _NS(_P("string"), _P("obj", "bool")); !got.Equals(want) {
+		t.Errorf("expected unowned fields to be %v, got %v", want, got)
+	}
+	// The fields under an owned field are owned too.
+	parent := fieldpath.ManagedFields{
+		"three": fieldpath.NewVersionedSet(_NS(_P("obj")), "v1", true),
+	}
+	if got, err := parent.Unowned(live, "v1"); err != nil {
+		t.Errorf("failed to get unowned fields: %v", err)
+	} else if want := _NS(_P("numeric"), _P("bool"), _P("string")); !got.Equals(want) {
+		t.Errorf("expected unowned fields to be %v, got %v", want, got)
+	}
+	if got, err := parent.OwnersOf(_P("obj", "bool"), "v1"); err != nil {
+		t.Errorf("failed to get owners of obj.bool: %v", err)
+	} else if want := []string{"three"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected owners of obj.bool to be %v, got %v", want, got)
+	}
+	if got, err := managers.Overlaps("v1"); err != nil {
+		t.Errorf("failed to get overlaps: %v", err)
+	} else if want := _NS(_P("numeric")); !got.Equals(want) {
+		t.Errorf("expected overlaps to be %v, got %v", want, got)
+	}
+
+	// Fields at different versions can't be compared.
+	managers["three"] = fieldpath.NewVersionedSet(_NS(_P("numeric")), "v2", true)
+	if _, err := managers.OwnersOf(_P("numeric"), "v1"); err == nil {
+		t.Error("expected an error for the owners of managers at different versions")
+	}
+	if _, err := managers.OwnedBy("three", "v1"); err == nil {
+		t.Error("expected an error for the fields of a manager at another version")
+	}
+	if _, err := managers.Unowned(live, "v1"); err == nil {
+		t.Error("expected an error for the unowned fields of managers at different versions")
+	}
+	if _, err := managers.Overlaps("v1"); err == nil {
+		t.Error("expected an error for the overlaps of managers at different versions")
+	}
+}
+
+func TestManagersMetadata(t *testing.T) {
+	metadata := fieldpath.ManagerMetadata{
+		Time:        time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Subresource: "status",
+		Labels:      map[string]string{"team": "platform"},
+		Contested:   _NS(_P("string")),
+		Deleted:     _NS(_P("bool")),
+	}
+	managers := fieldpath.ManagedFields{
+		"one": fieldpath.NewVersionedSetWithMetadata(
+			_NS(_P("numeric"), _P("string")),
+			"v1",
+			true,
+			metadata,
+		),
+	}
+	if got := fieldpath.MetadataOf(managers.Copy()["one"]); !reflect.DeepEqual(got, metadata) {
+		t.Errorf("expected copied metadata %+v, got %+v", metadata, got)
+	}
+
+	other := fieldpath.ManagedFields{
+		"one": fieldpath.NewVersionedSet(
+			_NS(_P("numeric")),
+			"v1",
+			true,
+		),
+	}
+	diff := other.Difference(managers)
+	if got := fieldpath.MetadataOf(diff["one"]); !reflect.DeepEqual(got, metadata) {
+		t.Errorf("expected metadata %+v in difference, got %+v", metadata, got)
+	}
+	empty := fieldpath.ManagerMetadata{Contested: fieldpath.NewSet(), Deleted: fieldpath.NewSet()}
+	if got := fieldpath.MetadataOf(other["one"]); !reflect.DeepEqual(got, empty) {
+		t.Errorf("expected no metadata, got %+v", got)
+	}
+
+	// Contested fields are part of the fields of the manager.
+	set := fieldpath.NewVersionedSetWithMetadata(_NS(_P("numeric")), "v1", true, metadata)
+	if got := fieldpath.MetadataOf(set).Contested; !got.Empty() {
+		t.Errorf("expected no contested fields outside of the set, got %v", got)
+	}
+}
+
+func TestScopedManager(t *testing.T) {
+	pairs := [][2]string{
+		{"ctrl", ""},
+		{"ctrl", "status"},
+		{"ctrl (status)", ""},
+		{"ctrl", "scale"},
+		{`{"manager":"ctrl","subresource":"status"}`, ""},
+		{`{"manager":"ctrl"}`, ""},
+		{"", "status"},
+	}
+	keys := map[string][2]string{}
+	for _, pair := range pairs {
+		key := fieldpath.ScopedManager(pair[0], pair[1])
+		if other, ok := keys[key]; ok {
+			t.Errorf("expected different keys for %q and %q, got %q", pair, other, key)
+		}
+		keys[key] = pair
+		if manager, subresource := fieldpath.ParseScopedManager(key); manager != pair[0] || subresource != pair[1] {
+			t.Errorf("expected key %q to be parsed as %q, got %q", key, pair, [2]string{manager, subresource})
+		}
+	}
+	if key := fieldpath.ScopedManager("ctrl", ""); key != "ctrl" {
+		t.Errorf("expected the key of a manager without subresource to be its name, got %q", key)
+	}
+}
@@ -0,0 +1,404 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v2"
+)
+
+// ManagedFieldsVersion is the version of the documents written by
+// ManagedFields.ToJSON and ManagedFields.ToYAML.
+const ManagedFieldsVersion = "v1"
+
+// managedFieldsEntry is the serialized form of the fields of a manager.
+// It has the shape of the managedFields entries of Kubernetes objects,
+// along with the labels, contested and deleted fields of the manager.
+// The fields are written in this order, and the empty ones are left out,
+// except for the manager and the operation.
+type managedFieldsEntry struct {
+	Manager     string
+	Operation   string
+	APIVersion  APIVersion
+	Time        *time.Time
+	FieldsType  string
+	FieldsV1    []byte
+	Subresource string
+	Labels      map[string]string
+	Contested   []byte
+	Deleted     []byte
+}
+
+const (
+	operationApply  = "Apply"
+	operationUpdate = "Update"
+	fieldsTypeV1    = "FieldsV1"
+)
+
+// ToJSON serializes the managers as a versioned document. The entries of
+// its managedFields list, sorted by manager, have the shape of the
+// managedFields entries of Kubernetes objects, and their operation is
+// Apply for applied fields. The keys of managers with a subresource are
+// split into the manager and its subresource (see ScopedManager).
+func (lhs ManagedFields) ToJSON() ([]byte, error) {
+	entries := make([]managedFieldsEntry, 0, len(lhs))
+	for manager, set := range lhs {
+		metadata := MetadataOf(set)
+		name, subresource := ParseScopedManager(manager)
+		entry := managedFieldsEntry{
+			Manager:    name,
+			Operation:  operationUpdate,
+			APIVersion: set.APIVersion(),
+			FieldsType: fieldsTypeV1,
+		}
+		if set.Applied() {
+			entry.Operation = operationApply
+		}
+		fields, err := set.Set().ToJSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize fields of %q: %v", manager, err)
+		}
+		entry.FieldsV1 = fields
+		if !metadata.Contested.Empty() {
+			if entry.Contested, err = metadata.Contested.ToJSON(); err != nil {
+				return nil, fmt.Errorf("failed to serialize contested fields of %q: %v", manager, err)
+			}
+		}
+		if !metadata.Deleted.Empty() {
+			if entry.Deleted, err = metadata.Deleted.ToJSON(); err != nil {
+				return nil, fmt.Errorf("failed to serialize deleted fields of %q: %v", manager, err)
+			}
+		}
+		if !metadata.Time.IsZero() {
+			t := metadata.Time
+			entry.Time = &t
+		}
+		entry.Subresource = subresource
+		entry.Labels = metadata.Labels
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Manager != entries[j].Manager {
+			return entries[i].Manager < entries[j].Manager
+		}
+		return entries[i].Subresource < entries[j].Subresource
+	})
+
+	buf := bytes.Buffer{}
+	stream := writePool.BorrowStream(&buf)
+	defer writePool.ReturnStream(stream)
+	stream.WriteObjectStart()
+	stream.WriteObjectField("version")
+	stream.WriteString(ManagedFieldsVersion)
+	stream.WriteMore()
+	stream.WriteObjectField("managedFields")
+	stream.WriteArrayStart()
+	for i := range entries {
+		if i > 0 {
+			stream.WriteMore()
+		}
+		entries[i].writeJSON(stream)
+	}
+	stream.WriteArrayEnd()
+	stream.WriteObjectEnd()
+	if err := stream.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes the entry as a JSON object.
+func (entry managedFieldsEntry) writeJSON(stream *jsoniter.Stream) {
+	stream.WriteObjectStart()
+	stream.WriteObjectField("manager")
+	stream.WriteString(entry.Manager)
+	stream.WriteMore()
+	stream.WriteObjectField("operation")
+	stream.WriteString(entry.Operation)
+	field := func(name string) {
+		stream.WriteMore()
+		stream.WriteObjectField(name)
+	}
+	if entry.APIVersion != "" {
+		field("apiVersion")
+		stream.WriteString(string(entry.APIVersion))
+	}
+	if entry.Time != nil {
+		field("time")
+		stream.WriteString(entry.Time.Format(time.RFC3339Nano))
+	}
+	if entry.FieldsType != "" {
+		field("fieldsType")
+		stream.WriteString(entry.FieldsType)
+	}
+	if len(entry.FieldsV1) != 0 {
+		field("fieldsV1")
+		stream.WriteRaw(string(entry.FieldsV1))
+	}
+	if entry.Subresource != "" {
+		field("subresource")
+		stream.WriteString(entry.Subresource)
+	}
+	if len(entry.Labels) != 0 {
+		field("labels")
+		keys := make([]string, 0, len(entry.Labels))
+		for key := range entry.Labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		stream.WriteObjectStart()
+		for i, key := range keys {
+			if i > 0 {
+				stream.WriteMore()
+			}
+			stream.WriteObjectField(key)
+			stream.WriteString(entry.Labels[key])
+		}
+		stream.WriteObjectEnd()
+	}
+	if len(entry.Contested) != 0 {
+		field("contested")
+		stream.WriteRaw(string(entry.Contested))
+	}
+	if len(entry.Deleted) != 0 {
+		field("deleted")
+		stream.WriteRaw(string(entry.Deleted))
+	}
+	stream.WriteObjectEnd()
+}
+
+// FromJSON replaces the managers with the ones read from a document
+// written by ToJSON. Managers with a subresource are keyed as given by
+// ScopedManager.
+func (lhs *ManagedFields) FromJSON(r io.Reader) error {
+	iter := jsoniter.Parse(jsoniter.ConfigCompatibleWithStandardLibrary, r, 4096)
+	var version string
+	var entries []managedFieldsEntry
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+		switch key {
+		case "version":
+			version = iter.ReadString()
+		case "managedFields":
+			iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+				var entry managedFieldsEntry
+				entry.readJSON(iter)
+				entries = append(entries, entry)
+				return iter.Error == nil
+			})
+		default:
+			iter.Skip()
+		}
+		return iter.Error == nil
+	})
+	if iter.Error != nil {
+		return fmt.Errorf("failed to parse managed fields: %v", iter.Error)
+	}
+	if version != ManagedFieldsVersion {
+		return fmt.Errorf("unsupported managed fields version %q, expected %q", version, ManagedFieldsVersion)
+	}
+	managers := ManagedFields{}
+	for _, entry := range entries {
+		manager := ScopedManager(entry.Manager, entry.Subresource)
+		if _, ok := managers[manager]; ok {
+			return fmt.Errorf("duplicate entry for manager %q", manager)
+		}
+		set, err := entry.toVersionedSet()
+		if err != nil {
+			return fmt.Errorf("invalid entry for manager %q: %v", manager, err)
+		}
+		managers[manager] = set
+	}
+	*lhs = managers
+	return nil
+}
+
+// readJSON reads the entry from a JSON object. Unknown fields are
+// ignored.
+func (entry *managedFieldsEntry) readJSON(iter *jsoniter.Iterator) {
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+		switch key {
+		case "manager":
+			entry.Manager = iter.ReadString()
+		case "operation":
+			entry.Operation = iter.ReadString()
+		case "apiVersion":
+			entry.APIVersion = APIVersion(iter.ReadString())
+		case "time":
+			if iter.ReadNil() {
+				break
+			}
+			t, err := time.Parse(time.RFC3339, iter.ReadString())
+			if err != nil {
+				iter.ReportError("parsing time", err.Error())
+				break
+			}
+			entry.Time = &t
+		case "fieldsType":
+			entry.FieldsType = iter.ReadString()
+		case "fieldsV1":
+			entry.FieldsV1 = iter.SkipAndReturnBytes()
+		case "subresource":
+			entry.Subresource = iter.ReadString()
+		case "labels":
+			if iter.ReadNil() {
+				break
+			}
+			entry.Labels = map[string]string{}
+			iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+				entry.Labels[key] = iter.ReadString()
+				return iter.Error == nil
+			})
+		case "contested":
+			entry.Contested = iter.SkipAndReturnBytes()
+		case "deleted":
+			entry.Deleted = iter.SkipAndReturnBytes()
+		default:
+			iter.Skip()
+		}
+		return iter.Error == nil
+	})
+}
+
+// toVersionedSet returns the fields of the entry's manager.
+func (entry managedFieldsEntry) toVersionedSet() (VersionedSet, error) {
+	var applied bool
+	switch entry.Operation {
+	case operationApply:
+		applied = true
+	case operationUpdate:
+	default:
+		return nil, fmt.Errorf("unknown operation %q", entry.Operation)
+	}
+	if entry.FieldsType != "" && entry.FieldsType != fieldsTypeV1 {
+		return nil, fmt.Errorf("unsupported fields type %q", entry.FieldsType)
+	}
+	set := NewSet()
+	if len(entry.FieldsV1) != 0 {
+		if err := set.FromJSON(bytes.NewReader(entry.FieldsV1)); err != nil {
+			return nil, fmt.Errorf("failed to parse fields: %v", err)
+		}
+	}
+	contested := NewSet()
+	if len(entry.Contested) != 0 {
+		if err := contested.FromJSON(bytes.NewReader(entry.Contested)); err != nil {
+			return nil, fmt.Errorf("failed to parse contested fields: %v", err)
+		}
+	}
+	deleted := NewSet()
+	if len(entry.Deleted) != 0 {
+		if err := deleted.FromJSON(bytes.NewReader(entry.Deleted)); err != nil {
+			return nil, fmt.Errorf("failed to parse deleted fields: %v", err)
+		}
+	}
+	metadata := ManagerMetadata{
+		Subresource: entry.Subresource,
+		Labels:      entry.Labels,
+		Contested:   contested,
+		Deleted:     deleted,
+	}
+	if entry.Time != nil {
+		metadata.Time = *entry.Time
+	}
+	return NewVersionedSetWithMetadata(set, entry.APIVersion, applied, metadata), nil
+}
+
+// ToYAML serializes the managers as the YAML form of the document written
+// by ToJSON.
+func (lhs ManagedFields) ToYAML() ([]byte, error) {
+	data, err := lhs.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	iter := jsoniter.ParseBytes(jsoniter.ConfigCompatibleWithStandardLibrary, data)
+	doc := iter.Read()
+	if iter.Error != nil {
+		return nil, iter.Error
+	}
+	return yaml.Marshal(doc)
+}
+
+// FromYAML replaces the managers with the ones read from a document
+// written by ToYAML or ToJSON.
+func (lhs *ManagedFields) FromYAML(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse managed fields: %v", err)
+	}
+	buf := bytes.Buffer{}
+	stream := writePool.BorrowStream(&buf)
+	defer writePool.ReturnStream(stream)
+	if err := writeJSONValue(stream, doc); err != nil {
+		return err
+	}
+	if err := stream.Flush(); err != nil {
+		return err
+	}
+	return lhs.FromJSON(&buf)
+}
+
+// writeJSONValue writes a document decoded from YAML as JSON. The keys
+// of its maps must be strings, and are written sorted.
+func writeJSONValue(stream *jsoniter.Stream, v interface{}) error {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			k, ok := key.(string)
+			if !ok {
+				return fmt.Errorf("invalid key %v of type %T, expected a string", key, key)
+			}
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		stream.WriteObjectStart()
+		for i, key := range keys {
+			if i > 0 {
+				stream.WriteMore()
+			}
+			stream.WriteObjectField(key)
+			if err := writeJSONValue(stream, v[key]); err != nil {
+				return err
+			}
+		}
+		stream.WriteObjectEnd()
+	case []interface{}:
+		stream.WriteArrayStart()
+		for i, item := range v {
+			if i > 0 {
+				stream.WriteMore()
+			}
+			if err := writeJSONValue(stream, item); err != nil {
+				return err
+			}
+		}
+		stream.WriteArrayEnd()
+	default:
+		stream.WriteVal(v)
+	}
+	return stream.Error
+}
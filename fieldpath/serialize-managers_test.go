@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fieldpath
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSerializeManagedFields(t *testing.T) {
+	managers := ManagedFields{
+		"kubectl": NewVersionedSetWithMetadata(
+			NewSet(
+				MakePathOrDie("metadata", "labels", "app"),
+				MakePathOrDie("spec", "ports", KeyByFields("name", "http")),
+				MakePathOrDie("spec", "ports", KeyByFields("name", "http"), "number"),
+			),
+			"v1",
+			true,
+			ManagerMetadata{
+				Time:      time.Date(2020, 1, 2, 3, 4, 5, 600, time.UTC),
+				Labels:    map[string]string{"team": "platform"},
+				Contested: NewSet(MakePathOrDie("metadata", "labels", "app")),
+			},
+		),
+		"controller": NewVersionedSet(
+			NewSet(MakePathOrDie("spec", "replicas")),
+			"v1",
+			false,
+		),
+		ScopedManager("controller", "status"): NewVersionedSetWithMetadata(
+			NewSet(MakePathOrDie("status", "replicas")),
+			"v2",
+			false,
+			ManagerMetadata{Subresource: "status"},
+		),
+		// Not to be confused with the controller in the status scope.
+		"controller (status)": NewVersionedSet(
+			NewSet(MakePathOrDie("spec", "paused")),
+			"v1",
+			false,
+		),
+		"empty": NewVersionedSet(NewSet(), "v1", false),
+		"pruner": NewVersionedSetWithMetadata(
+			NewSet(MakePathOrDie("spec", "paused")),
+			"v1",
+			true,
+			ManagerMetadata{Deleted: NewSet(MakePathOrDie("spec", "suspend"))},
+		),
+	}
+
+	data, err := managers.ToJSON()
+	if err != nil {
+		t.Fatalf("failed to serialize: %v", err)
+	}
+	expected := `{"version":"v1","managedFields":[` +
+		`{"manager":"controller","operation":"Update","apiVersion":"v1","fieldsType":"FieldsV1","fieldsV1":{"f:spec":{"f:replicas":{}}}},` +
+		`{"manager":"controller","operation":"Update","apiVersion":"v2","fieldsType":"FieldsV1","fieldsV1":{"f:status":{"f:replicas":{}}},"subresource":"status"},` +
+		`{"manager":"controller (status)","operation":"Update","apiVersion":"v1","fieldsType":"FieldsV1","fieldsV1":{"f:spec":{"f:paused":{}}}},` +
+		`{"manager":"empty","operation":"Update","apiVersion":"v1","fieldsType":"FieldsV1","fieldsV1":{}},` +
+		`{"manager":"kubectl","operation":"Apply","apiVersion":"v1","time":"2020-01-02T03:04:05.0000006Z","fieldsType":"FieldsV1",` +
+		`"fieldsV1":{"f:metadata":{"f:labels":{"f:app":{}}},"f:spec":{"f:ports":{"k:{\"name\":\"http\"}":{".":{},"f:number":{}}}}},` +
+		`"labels":{"team":"platform"},"contested":{"f:metadata":{"f:labels":{"f:app":{}}}}},` +
+		`{"manager":"pruner","operation":"Apply","apiVersion":"v1","fieldsType":"FieldsV1","fieldsV1":{"f:spec":{"f:paused":{}}},` +
+		`"deleted":{"f:spec":{"f:suspend":{}}}}]}`
+	if string(data) != expected {
+		t.Errorf("expected JSON:\n%v\ngot:\n%v", expected, string(data))
+	}
+	var decoded ManagedFields
+	if err := decoded.FromJSON(bytes.NewReader(data)); err != nil {
+		t.Fatalf("failed to deserialize: %v", err)
+	}
+	checkManagedFieldsRoundTrip(t, managers, decoded)
+
+	yaml, err := managers.ToYAML()
+	if err != nil {
+		t.Fatalf("failed to serialize to YAML: %v", err)
+	}
+	decoded = nil
+	if err := decoded.FromYAML(bytes.NewReader(yaml)); err != nil {
+		t.Fatalf("failed to deserialize YAML:\n%s\n%v", yaml, err)
+	}
+	checkManagedFieldsRoundTrip(t, managers, decoded)
+}
+
+func checkManagedFieldsRoundTrip(t *testing.T, expected, got ManagedFields) {
+	t.Helper()
+	if !got.Equals(expected) {
+		t.Fatalf("expected managers:\n%v\ngot:\n%v", expected, got)
+	}
+	for manager, set := range expected {
+		want, have := MetadataOf(set), MetadataOf(got[manager])
+		if !want.Time.Equal(have.Time) || want.Subresource != have.Subresource || !reflect.DeepEqual(want.Labels, have.Labels) {
+			t.Errorf("expected metadata of %q to be %+v, got %+v", manager, want, have)
+		}
+	}
+}
+
+func TestDeserializeManagedFieldsErrors(t *testing.T) {
+	for name, doc := range map[string]string{
+		"missing version":   `{"managedFields":[]}`,
+		"unknown version":   `{"version":"v2","managedFields":[]}`,
+		"unknown operation": `{"version":"v1","managedFields":[{"manager":"a","operation":"Patch"}]}`,
+		"unknown type":      `{"version":"v1","managedFields":[{"manager":"a","operation":"Apply","fieldsType":"FieldsV2"}]}`,
+		"duplicate manager": `{"version":"v1","managedFields":[{"manager":"a","operation":"Apply"},{"manager":"a","operation":"Update"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var managers ManagedFields
+			if err := managers.FromJSON(strings.NewReader(doc)); err == nil {
+				t.Errorf("expected an error, got %v", managers)
+			}
+		})
+	}
+}
@@ -94,6 +94,15 @@ func TestMerge(t *testing.T) {
 			rhsPath:    testdata("bad-scalar.yaml"),
 		},
 		expectedOutputPath: testdata("bad-scalar.yaml"),
+	}, {
+		options: Options{
+			schemaPath: testdata("secret-schema.yaml"),
+			merge:      true,
+			lhsPath:    testdata("secret.yaml"),
+			rhsPath:    testdata("secret.yaml"),
+		},
+		// The merged object is data, and is never redacted.
+		expectedOutputPath: testdata("secret.yaml"),
 	}, {
 		options: Options{
 			schemaPath: testdata("schema.yaml"),
@@ -159,6 +168,23 @@ func TestCompare(t *testing.T) {
 		// just to make sure the command output stays sane. All the
 		// actual operations are unit tested.
 		expectedOutputPath: testdata("scalar-compare-output.txt"),
+	}, {
+		options: Options{
+			schemaPath: testdata("secret-schema.yaml"),
+			compare:    true,
+			lhsPath:    testdata("secret.yaml"),
+			rhsPath:    testdata("secret-rotated.yaml"),
+			redact:     true,
+		},
+		expectedOutputPath: testdata("secret-compare-redacted.txt"),
+	}, {
+		options: Options{
+			schemaPath: testdata("secret-schema.yaml"),
+			compare:    true,
+			lhsPath:    testdata("secret.yaml"),
+			rhsPath:    testdata("secret-rotated.yaml"),
+		},
+		expectedOutputPath: testdata("secret-compare-output.txt"),
 	}, {
 		options: Options{
 			schemaPath: testdata("schema.yaml"),
@@ -249,3 +275,66 @@ func TestFieldSet(t *testing.T) {
 		})
 	}
 }
+
+func TestOwners(t *testing.T) {
+	cases := []testCase{{
+		options: Options{
+			schemaPath: testdata("schema.yaml"),
+			owners:     testdata("scalar-managed.yaml"),
+			livePath:   testdata("scalar.yaml"),
+		},
+		expectedOutputPath: testdata("scalar-owners-output.txt"),
+	}, {
+		options: Options{
+			schemaPath: testdata("secret-schema.yaml"),
+			owners:     testdata("secret-managed.yaml"),
+			livePath:   testdata("secret-rotated.yaml"),
+			redact:     true,
+		},
+		expectedOutputPath: testdata("secret-owners-redacted.txt"),
+	}, {
+		options: Options{
+			schemaPath: testdata("schema.yaml"),
+			owners:     testdata("scalar.yaml"),
+		},
+		expectErr: true,
+	}, {
+		options: Options{
+			schemaPath: testdata("schema.yaml"),
+			owners:     testdata("mixed-versions-managed.yaml"),
+		},
+		expectErr: true,
+	}}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.options.owners, func(t *testing.T) {
+			op, err := tt.options.Resolve()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			err = op.Execute(&b)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("unexpected success")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.checkOutput(t, b.Bytes())
+		})
+	}
+}
+
+func TestLiveNeedsOwners(t *testing.T) {
+	options := Options{
+		schemaPath:   testdata("schema.yaml"),
+		validatePath: testdata("scalar.yaml"),
+		livePath:     testdata("scalar.yaml"),
+	}
+	if _, err := options.Resolve(); err != ErrLiveNeedsOwners {
+		t.Errorf("expected %v, got %v", ErrLiveNeedsOwners, err)
+	}
+}
@@ -17,10 +17,14 @@ limitations under the License.
 package cli
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sort"
+	"strings"
 
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
 	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
@@ -32,6 +36,9 @@ type Operation interface {
 type operationBase struct {
 	parser   *typed.Parser
 	typeName string
+
+	// if set, the sensitive values found in diagnostics are redacted
+	redact bool
 }
 
 func (b operationBase) parseFile(path string) (tv *typed.TypedValue, err error) {
@@ -46,6 +53,29 @@ func (b operationBase) parseFile(path string) (tv *typed.TypedValue, err error)
 	return tv, nil
 }
 
+// readManagedFields reads managed fields serialized in JSON or YAML, as
+// written by fieldpath.ManagedFields.ToJSON or ToYAML.
+func (b operationBase) readManagedFields(path string) (fieldpath.ManagedFields, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file %q: %v", path, err)
+	}
+	var managers fieldpath.ManagedFields
+	if err := managers.FromYAML(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("unable to parse managed fields %q: %v", path, err)
+	}
+	return managers, nil
+}
+
+// redactComparison redacts the sensitive values found in the paths of c,
+// if diagnostics should be redacted.
+func (b operationBase) redactComparison(c *typed.Comparison) *typed.Comparison {
+	if !b.redact {
+		return c
+	}
+	return c.Redacted()
+}
+
 type validation struct {
 	operationBase
 
@@ -153,7 +183,78 @@ func (c compare) Execute(w io.Writer) error {
 	// TODO: I think it'd be neat if we actually emitted a machine-readable
 	// format.
 
-	_, err = fmt.Fprintf(w, got.String())
+	_, err = fmt.Fprintf(w, c.redactComparison(got).String())
 
 	return err
 }
+
+type owners struct {
+	operationBase
+
+	managedFields string
+	live          string
+}
+
+func (o owners) Execute(w io.Writer) error {
+	managers, err := o.readManagedFields(o.managedFields)
+	if err != nil {
+		return err
+	}
+	// There is no converter, so all managers must be at the same
+	// version.
+	var version fieldpath.APIVersion
+	fields := fieldpath.NewSet()
+	for _, set := range managers {
+		version = set.APIVersion()
+		fields = fields.Union(set.Set())
+	}
+	if o.live != "" {
+		tv, err := o.parseFile(o.live)
+		if err != nil {
+			return err
+		}
+		liveFields, err := tv.ToFieldSet()
+		if err != nil {
+			return err
+		}
+		// Every live field is listed, even if it is only owned through
+		// a field it is under, or not at all.
+		fields = fields.Union(liveFields)
+	}
+
+	pt := o.parser.Type(o.typeName)
+	redactor := typed.NewPathRedactor(pt.Schema, pt.TypeRef)
+	fields.Iterate(func(p fieldpath.Path) {
+		if err != nil {
+			return
+		}
+		var keys []string
+		keys, err = managers.OwnersOf(p, version)
+		if err != nil {
+			return
+		}
+		owners := []string{}
+		for _, key := range keys {
+			owners = append(owners, ownerName(key))
+		}
+		sort.Strings(owners)
+		if len(owners) == 0 {
+			owners = append(owners, "(unowned)")
+		}
+		if o.redact {
+			p = redactor.RedactPath(p)
+		}
+		_, err = fmt.Fprintf(w, "%v: %v\n", p, strings.Join(owners, ", "))
+	})
+	return err
+}
+
+// ownerName returns the name of the manager whose key in the managed
+// fields is key, followed by its subresource, if any.
+func ownerName(key string) string {
+	manager, subresource := fieldpath.ParseScopedManager(key)
+	if subresource == "" {
+		return manager
+	}
+	return manager + "/" + subresource
+}
@@ -28,8 +28,9 @@ import (
 )
 
 var (
-	ErrTooManyOperations = errors.New("exactly one of --merge, --compare, --validate or --fieldset must be provided")
+	ErrTooManyOperations = errors.New("exactly one of --merge, --compare, --validate, --fieldset or --owners must be provided")
 	ErrNeedTwoArgs       = errors.New("--merge and --compare require both --lhs and --rhs")
+	ErrLiveNeedsOwners   = errors.New("--live requires --owners")
 )
 
 type Options struct {
@@ -38,16 +39,24 @@ type Options struct {
 
 	output string
 
+	// if set, the values of fields marked as sensitive in the schema are
+	// redacted from diagnostics (--compare, --owners)
+	redact bool
+
 	// options determining the operation to perform
 	listTypes    bool
 	validatePath string
 	merge        bool
 	compare      bool
 	fieldset     string
+	owners       string
 
 	// arguments for merge or compare
 	lhsPath string
 	rhsPath string
+
+	// optional live object for owners
+	livePath string
 }
 
 func (o *Options) AddFlags(fs *flag.FlagSet) {
@@ -55,6 +64,7 @@ func (o *Options) AddFlags(fs *flag.FlagSet) {
 	fs.StringVar(&o.typeName, "type-name", "", "Name of type in the schema to use. If empty, the first type in the schema will be used.")
 
 	fs.StringVar(&o.output, "output", "-", "Output location (if the command has output). '-' means stdout.")
+	fs.BoolVar(&o.redact, "redact", true, "Redact the values of fields marked as sensitive in the schema from the output of --compare and --owners. The output of --merge and --fieldset is data and never redacted.")
 
 	// The three supported operations. We could make these into subcommands
 	// and that would probably make more sense, but this is easy and this
@@ -65,14 +75,16 @@ func (o *Options) AddFlags(fs *flag.FlagSet) {
 	fs.BoolVar(&o.merge, "merge", false, "Perform a merge operation between --lhs and --rhs")
 	fs.BoolVar(&o.compare, "compare", false, "Perform a compare operation between --lhs and --rhs")
 	fs.StringVar(&o.fieldset, "fieldset", "", "Path to a file for which we should build a fieldset.")
+	fs.StringVar(&o.owners, "owners", "", "Path to a file with managed fields, in JSON or YAML, for which we should list the owners of each field. The fields of all managers must be at the same version.")
 
 	fs.StringVar(&o.lhsPath, "lhs", "", "Path to a file containing the left hand side of the operation")
 	fs.StringVar(&o.rhsPath, "rhs", "", "Path to a file containing the right hand side of the operation")
+	fs.StringVar(&o.livePath, "live", "", "Path to a file containing the live object for --owners, whose fields that no manager owns are listed too")
 }
 
 // resolve turns options in to an operation that can be executed.
 func (o *Options) Resolve() (Operation, error) {
-	var base operationBase
+	base := operationBase{redact: o.redact}
 	if o.schemaPath == "" {
 		return nil, errors.New("a schema is required")
 	}
@@ -97,10 +109,13 @@ func (o *Options) Resolve() (Operation, error) {
 
 	// Count how many operations were requested
 	c := map[bool]int{true: 1}
-	count := c[o.merge] + c[o.compare] + c[o.validatePath != ""] + c[o.listTypes] + c[o.fieldset != ""]
+	count := c[o.merge] + c[o.compare] + c[o.validatePath != ""] + c[o.listTypes] + c[o.fieldset != ""] + c[o.owners != ""]
 	if count > 1 {
 		return nil, ErrTooManyOperations
 	}
+	if o.livePath != "" && o.owners == "" {
+		return nil, ErrLiveNeedsOwners
+	}
 
 	switch {
 	case o.listTypes:
@@ -119,6 +134,8 @@ func (o *Options) Resolve() (Operation, error) {
 		return compare{base, o.lhsPath, o.rhsPath}, nil
 	case o.fieldset != "":
 		return fieldset{base, o.fieldset}, nil
+	case o.owners != "":
+		return owners{base, o.owners, o.livePath}, nil
 	}
 	return nil, errors.New("no operation requested")
 }
@@ -110,6 +110,11 @@ func (s *State) checkInit(version fieldpath.APIVersion) error {
 }
 
 func (s *State) UpdateObject(tv *typed.TypedValue, version fieldpath.APIVersion, manager string) error {
+	return s.UpdateObjectWithOptions(tv, version, manager, merge.Options{})
+}
+
+// UpdateObjectWithOptions updates the object with the given options.
+func (s *State) UpdateObjectWithOptions(tv *typed.TypedValue, version fieldpath.APIVersion, manager string, opts merge.Options) error {
 	err := s.checkInit(version)
 	if err != nil {
 		return err
@@ -118,7 +123,7 @@ func (s *State) UpdateObject(tv *typed.TypedValue, version fieldpath.APIVersion,
 	if err != nil {
 		return err
 	}
-	newObj, managers, err := s.Updater.Update(s.Live, tv, version, s.Managers, manager)
+	newObj, managers, err := s.Updater.UpdateWithOptions(s.Live, tv, version, s.Managers, manager, opts)
 	if err != nil {
 		return err
 	}
@@ -138,6 +143,11 @@ func (s *State) Update(obj typed.YAMLObject, version fieldpath.APIVersion, manag
 }
 
 func (s *State) ApplyObject(tv *typed.TypedValue, version fieldpath.APIVersion, manager string, force bool) error {
+	return s.ApplyObjectWithOptions(tv, version, manager, force, merge.Options{})
+}
+
+// ApplyObjectWithOptions applies the object with the given options.
+func (s *State) ApplyObjectWithOptions(tv *typed.TypedValue, version fieldpath.APIVersion, manager string, force bool, opts merge.Options) error {
 	err := s.checkInit(version)
 	if err != nil {
 		return err
@@ -146,7 +156,7 @@ func (s *State) ApplyObject(tv *typed.TypedValue, version fieldpath.APIVersion,
 	if err != nil {
 		return err
 	}
-	new, managers, err := s.Updater.Apply(s.Live, tv, version, s.Managers, manager, force)
+	new, managers, err := s.Updater.ApplyWithOptions(s.Live, tv, version, s.Managers, manager, force, opts)
 	if err != nil {
 		return err
 	}
@@ -229,11 +239,17 @@ func addedConflicts(one, other merge.Conflicts) merge.Conflicts {
 // Apply is a type of operation. It is a non-forced apply run by a
 // manager with a given object. Since non-forced apply operation can
 // conflict, the user can specify the expected conflicts. If conflicts
-// don't match, an error will occur.
+// don't match, an error will occur. Deletions, if any, are explicitly
+// deleted by the apply. If Forced is set, the conflicts on these fields
+// are forced, and only the other conflicts are expected. If Scope is
+// set, the apply is restricted to it.
 type Apply struct {
 	Manager    string
 	APIVersion fieldpath.APIVersion
 	Object     typed.YAMLObject
+	Deletions  *fieldpath.Set
+	Forced     *fieldpath.Set
+	Scope      *merge.Scope
 	Conflicts  merge.Conflicts
 }
 
@@ -256,6 +272,9 @@ func (a Apply) preprocess(parser Parser) (Operation, error) {
 		Manager:    a.Manager,
 		APIVersion: a.APIVersion,
 		Object:     tv,
+		Deletions:  a.Deletions,
+		Forced:     a.Forced,
+		Scope:      a.Scope,
 		Conflicts:  a.Conflicts,
 	}, nil
 }
@@ -264,13 +283,20 @@ type ApplyObject struct {
 	Manager    string
 	APIVersion fieldpath.APIVersion
 	Object     *typed.TypedValue
+	Deletions  *fieldpath.Set
+	Forced     *fieldpath.Set
+	Scope      *merge.Scope
 	Conflicts  merge.Conflicts
 }
 
 var _ Operation = &ApplyObject{}
 
 func (a ApplyObject) run(state *State) error {
-	err := state.ApplyObject(a.Object, a.APIVersion, a.Manager, false)
+	err := state.ApplyObjectWithOptions(a.Object, a.APIVersion, a.Manager, false, merge.Options{
+		Deletions: a.Deletions,
+		Forced:    a.Forced,
+		Scope:     a.Scope,
+	})
 	if err != nil {
 		if _, ok := err.(merge.Conflicts); !ok || a.Conflicts == nil {
 			return err
@@ -303,12 +329,17 @@ type ForceApply struct {
 	Manager    string
 	APIVersion fieldpath.APIVersion
 	Object     typed.YAMLObject
+	Deletions  *fieldpath.Set
 }
 
 var _ Operation = &ForceApply{}
 
 func (f ForceApply) run(state *State) error {
-	return state.Apply(f.Object, f.APIVersion, f.Manager, true)
+	p, err := f.preprocess(state.Parser)
+	if err != nil {
+		return err
+	}
+	return p.run(state)
 }
 
 func (f ForceApply) preprocess(parser Parser) (Operation, error) {
@@ -320,6 +351,7 @@ func (f ForceApply) preprocess(parser Parser) (Operation, error) {
 		Manager:    f.Manager,
 		APIVersion: f.APIVersion,
 		Object:     tv,
+		Deletions:  f.Deletions,
 	}, nil
 }
 
@@ -329,30 +361,116 @@ type ForceApplyObject struct {
 	Manager    string
 	APIVersion fieldpath.APIVersion
 	Object     *typed.TypedValue
+	Deletions  *fieldpath.Set
 }
 
 var _ Operation = &ForceApplyObject{}
 
 func (f ForceApplyObject) run(state *State) error {
-	return state.ApplyObject(f.Object, f.APIVersion, f.Manager, true)
+	return state.ApplyObjectWithOptions(f.Object, f.APIVersion, f.Manager, true, merge.Options{Deletions: f.Deletions})
 }
 
 func (f ForceApplyObject) preprocess(parser Parser) (Operation, error) {
 	return f, nil
 }
 
+// PlanApply is a type of operation. It plans an apply by a manager with
+// a given object, and checks the conflicts, pruned fields and moved
+// ownership of the plan, if they are specified. It also checks that the
+// plan matches the result of a forced apply, and leaves the state
+// unchanged.
+type PlanApply struct {
+	Manager    string
+	APIVersion fieldpath.APIVersion
+	Object     typed.YAMLObject
+	Conflicts  merge.Conflicts
+	Pruned     *fieldpath.Set
+	Moved      fieldpath.ManagedFields
+}
+
+var _ Operation = &PlanApply{}
+
+func (p PlanApply) run(state *State) error {
+	if err := state.checkInit(p.APIVersion); err != nil {
+		return err
+	}
+	tv, err := state.Parser.Type(string(p.APIVersion)).FromYAML(FixTabsOrDie(p.Object))
+	if err != nil {
+		return err
+	}
+	live, err := state.Updater.Converter.Convert(state.Live, p.APIVersion)
+	if err != nil {
+		return err
+	}
+	before := state.Managers.Copy()
+	liveYAML, err := value.ToYAML(live.AsValue())
+	if err != nil {
+		return err
+	}
+	plan, err := state.Updater.PlanApply(live, tv, p.APIVersion, state.Managers, p.Manager)
+	if err != nil {
+		return err
+	}
+	if !state.Managers.Equals(before) {
+		return fmt.Errorf("planning changed the managers:\n%v\nto:\n%v", before, state.Managers)
+	}
+	if after, err := value.ToYAML(live.AsValue()); err != nil || !bytes.Equal(after, liveYAML) {
+		return fmt.Errorf("planning changed the live object:\n%s\nto:\n%s", liveYAML, after)
+	}
+
+	if p.Conflicts != nil && !p.Conflicts.Equals(plan.Conflicts) {
+		return fmt.Errorf("expected conflicts:\n%v\ngot:\n%v", p.Conflicts.Error(), plan.Conflicts.Error())
+	}
+	if p.Pruned != nil && !p.Pruned.Equals(plan.Pruned) {
+		return fmt.Errorf("expected pruned fields:\n%v\ngot:\n%v", p.Pruned, plan.Pruned)
+	}
+	if p.Moved != nil && !p.Moved.Equals(plan.Moved) {
+		return fmt.Errorf("expected moved fields:\n%v\ngot:\n%v", p.Moved, plan.Moved)
+	}
+
+	newObject, managers, err := state.Updater.Apply(live, tv, p.APIVersion, before, p.Manager, true)
+	if err != nil {
+		return err
+	}
+	if (newObject != nil) != plan.Changed {
+		return fmt.Errorf("expected the object to be changed: %v, got %v", plan.Changed, newObject != nil)
+	}
+	if newObject != nil {
+		comparison, err := newObject.Compare(plan.Object)
+		if err != nil {
+			return err
+		}
+		if !comparison.IsSame() {
+			return fmt.Errorf("planned object differs from the applied object:\n%v", comparison)
+		}
+	}
+	if !managers.Equals(plan.Managers) {
+		return fmt.Errorf("planned managers:\n%v\ndiffer from the applied managers:\n%v", plan.Managers, managers)
+	}
+	return nil
+}
+
+func (p PlanApply) preprocess(parser Parser) (Operation, error) {
+	return p, nil
+}
+
 // Update is a type of operation. It is a controller type of
 // update. Errors are passed along.
 type Update struct {
 	Manager    string
 	APIVersion fieldpath.APIVersion
 	Object     typed.YAMLObject
+	Scope      *merge.Scope
 }
 
 var _ Operation = &Update{}
 
 func (u Update) run(state *State) error {
-	return state.Update(u.Object, u.APIVersion, u.Manager)
+	p, err := u.preprocess(state.Parser)
+	if err != nil {
+		return err
+	}
+	return p.run(state)
 }
 
 func (u Update) preprocess(parser Parser) (Operation, error) {
@@ -364,27 +482,70 @@ func (u Update) preprocess(parser Parser) (Operation, error) {
 		Manager:    u.Manager,
 		APIVersion: u.APIVersion,
 		Object:     tv,
+		Scope:      u.Scope,
 	}, nil
 }
 
 // UpdateObject is a type of operation. It is a controller type of
-// update. Errors are passed along.
+// update, restricted to Scope if it is set. Errors are passed along.
 type UpdateObject struct {
 	Manager    string
 	APIVersion fieldpath.APIVersion
 	Object     *typed.TypedValue
+	Scope      *merge.Scope
 }
 
 var _ Operation = &Update{}
 
 func (u UpdateObject) run(state *State) error {
-	return state.UpdateObject(u.Object, u.APIVersion, u.Manager)
+	return state.UpdateObjectWithOptions(u.Object, u.APIVersion, u.Manager, merge.Options{Scope: u.Scope})
 }
 
 func (f UpdateObject) preprocess(parser Parser) (Operation, error) {
 	return f, nil
 }
 
+// TransferOwnership is a type of operation. It moves the ownership of
+// Fields, given at the version of From, from one manager to another.
+type TransferOwnership struct {
+	From   string
+	To     string
+	Fields *fieldpath.Set
+}
+
+var _ Operation = &TransferOwnership{}
+
+func (t TransferOwnership) run(state *State) error {
+	managers, err := state.Updater.TransferOwnership(state.Live, state.Managers, t.From, t.To, t.Fields)
+	if err != nil {
+		return err
+	}
+	state.Managers = managers
+	return nil
+}
+
+func (t TransferOwnership) preprocess(_ Parser) (Operation, error) {
+	return t, nil
+}
+
+// ReleaseOwnership is a type of operation. The manager stops owning
+// Fields, without changing the object.
+type ReleaseOwnership struct {
+	Manager string
+	Fields  *fieldpath.Set
+}
+
+var _ Operation = &ReleaseOwnership{}
+
+func (r ReleaseOwnership) run(state *State) error {
+	state.Managers = state.Updater.ReleaseOwnership(state.Managers, r.Manager, r.Fields)
+	return nil
+}
+
+func (r ReleaseOwnership) preprocess(_ Parser) (Operation, error) {
+	return r, nil
+}
+
 // ChangeParser is a type of operation. It simulates making changes a schema without versioning
 // the schema. This can be used to test the behavior of making backward compatible schema changes,
 // e.g. setting "elementRelationship: atomic" on an existing struct. It also may be used to ensure
@@ -430,10 +591,11 @@ type TestCase struct {
 	// Managed, if not nil, is the ManagedFields as expected
 	// after all operations are run.
 	Managed fieldpath.ManagedFields
-	// Set to true if the test case needs the union behavior enabled.
-	RequiresUnions bool
 	// IgnoredFields containing the set to ignore for every version
 	IgnoredFields map[fieldpath.APIVersion]*fieldpath.Set
+	// Sharing, if not nil, is the policy of the fields that managers
+	// share.
+	Sharing merge.SharingPolicy
 }
 
 // Test runs the test-case using the given parser and a dummy converter.
@@ -465,12 +627,9 @@ func (tc TestCase) PreprocessOperations(parser Parser) error {
 // actually passes..
 func (tc TestCase) BenchWithConverter(parser Parser, converter merge.Converter) error {
 	state := State{
-		Updater: &merge.Updater{Converter: converter, IgnoredFields: tc.IgnoredFields},
+		Updater: &merge.Updater{Converter: converter, IgnoredFields: tc.IgnoredFields, Sharing: tc.Sharing},
 		Parser:  parser,
 	}
-	if tc.RequiresUnions {
-		state.Updater.EnableUnionFeature()
-	}
 	// We currently don't have any test that converts, we can take
 	// care of that later.
 	for i, ops := range tc.Ops {
@@ -485,12 +644,9 @@ func (tc TestCase) BenchWithConverter(parser Parser, converter merge.Converter)
 // TestWithConverter runs the test-case using the given parser and converter.
 func (tc TestCase) TestWithConverter(parser Parser, converter merge.Converter) error {
 	state := State{
-		Updater: &merge.Updater{Converter: converter, IgnoredFields: tc.IgnoredFields},
+		Updater: &merge.Updater{Converter: converter, IgnoredFields: tc.IgnoredFields, Sharing: tc.Sharing},
 		Parser:  parser,
 	}
-	if tc.RequiresUnions {
-		state.Updater.EnableUnionFeature()
-	}
 	for i, ops := range tc.Ops {
 		err := ops.run(&state)
 		if err != nil {
@@ -513,24 +669,23 @@ func (tc TestCase) TestWithConverter(parser Parser, converter merge.Converter) e
 		if diff := state.Managers.Difference(tc.Managed); len(diff) != 0 {
 			return fmt.Errorf("expected Managers to be:\n%v\ngot:\n%v", tc.Managed, state.Managers)
 		}
+		if diff := state.Managers.Contested().Difference(tc.Managed.Contested()); len(diff) != 0 {
+			return fmt.Errorf("expected contested Managers to be:\n%v\ngot:\n%v", tc.Managed, state.Managers)
+		}
+		for manager, set := range tc.Managed {
+			if !fieldpath.MetadataOf(state.Managers[manager]).Deleted.Equals(fieldpath.MetadataOf(set).Deleted) {
+				return fmt.Errorf("expected deleted fields of Managers to be:\n%v\ngot:\n%v", tc.Managed, state.Managers)
+			}
+		}
 	}
 
-	// Fail if any empty sets are present in the managers
+	// Fail if any empty sets are present in the managers, unless they
+	// record deleted fields.
 	for manager, set := range state.Managers {
-		if set.Set().Empty() {
+		if set.Set().Empty() && fieldpath.MetadataOf(set).Deleted.Empty() {
 			return fmt.Errorf("expected Managers to have no empty sets, but found one managed by %v", manager)
 		}
 	}
 
-	if !tc.RequiresUnions {
-		// Re-run the test with unions on.
-		tc2 := tc
-		tc2.RequiresUnions = true
-		err := tc2.TestWithConverter(parser, converter)
-		if err != nil {
-			return fmt.Errorf("fails if unions are on: %v", err)
-		}
-	}
-
 	return nil
 }
@@ -17,11 +17,14 @@ limitations under the License.
 package merge
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
 
 // Conflict is a conflict on a specific field with the current manager of
@@ -30,6 +33,22 @@ import (
 type Conflict struct {
 	Manager string
 	Path    fieldpath.Path
+
+	// The following details are optional, and are set for the conflicts
+	// returned by the Updater.
+
+	// APIVersion is the version of the manager's fields, which is also
+	// the version of Path and of the values.
+	APIVersion fieldpath.APIVersion
+	// Applied is true if the manager applied the field, false if it
+	// updated it.
+	Applied bool
+	// Current is the value of the field in the live object, nil if the
+	// field isn't set.
+	Current value.Value
+	// Requested is the value of the field requested by the conflicting
+	// change, nil if the change removes the field.
+	Requested value.Value
 }
 
 // Conflict is an error.
@@ -40,7 +59,8 @@ func (c Conflict) Error() string {
 	return fmt.Sprintf("conflict with %q: %v", c.Manager, c.Path)
 }
 
-// Equals returns true if c == c2
+// Equals returns true if c and c2 are about the same field and manager.
+// The optional details aren't compared.
 func (c Conflict) Equals(c2 Conflict) bool {
 	if c.Manager != c2.Manager {
 		return false
@@ -48,6 +68,77 @@ func (c Conflict) Equals(c2 Conflict) bool {
 	return c.Path.Equals(c2.Path)
 }
 
+// conflictJSON is the serialized form of a Conflict. Path elements use the
+// same encoding as the keys of serialized fieldpath.Sets.
+type conflictJSON struct {
+	Manager    string               `json:"manager"`
+	Field      string               `json:"field,omitempty"`
+	Path       []string             `json:"path,omitempty"`
+	APIVersion fieldpath.APIVersion `json:"apiVersion,omitempty"`
+	Applied    *bool                `json:"applied,omitempty"`
+	Current    interface{}          `json:"current,omitempty"`
+	Requested  interface{}          `json:"requested,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Conflict) MarshalJSON() ([]byte, error) {
+	out := conflictJSON{
+		Manager:    c.Manager,
+		APIVersion: c.APIVersion,
+	}
+	if len(c.Path) > 0 {
+		out.Field = c.Path.String()
+	}
+	for _, pe := range c.Path {
+		s, err := fieldpath.SerializePathElement(pe)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize path element %v: %v", pe, err)
+		}
+		out.Path = append(out.Path, s)
+	}
+	if c.APIVersion != "" {
+		applied := c.Applied
+		out.Applied = &applied
+	}
+	if c.Current != nil {
+		out.Current = toUnstructured(c.Current)
+	}
+	if c.Requested != nil {
+		out.Requested = toUnstructured(c.Requested)
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Conflict) UnmarshalJSON(data []byte) error {
+	var in conflictJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*c = Conflict{
+		Manager:    in.Manager,
+		APIVersion: in.APIVersion,
+		Path:       fieldpath.Path{},
+	}
+	for _, s := range in.Path {
+		pe, err := fieldpath.DeserializePathElement(s)
+		if err != nil {
+			return fmt.Errorf("failed to deserialize path element %q: %v", s, err)
+		}
+		c.Path = append(c.Path, pe)
+	}
+	if in.Applied != nil {
+		c.Applied = *in.Applied
+	}
+	if in.Current != nil {
+		c.Current = value.NewValueInterface(in.Current)
+	}
+	if in.Requested != nil {
+		c.Requested = value.NewValueInterface(in.Requested)
+	}
+	return nil
+}
+
 // Conflicts accumulates multiple conflicts and aggregates them by managers.
 type Conflicts []Conflict
 
@@ -111,11 +202,203 @@ func ConflictsFromManagers(sets fieldpath.ManagedFields) Conflicts {
 	for manager, set := range sets {
 		set.Set().Iterate(func(p fieldpath.Path) {
 			conflicts = append(conflicts, Conflict{
-				Manager: manager,
-				Path:    p,
+				Manager:    manager,
+				Path:       p,
+				APIVersion: set.APIVersion(),
+				Applied:    set.Applied(),
 			})
 		})
 	}
 
 	return conflicts
 }
+
+// conflictsWithValues returns the conflicts, with the values of the
+// conflicting fields in oldObject and newObject, at the version of each
+// manager. Values are left out if the objects can't be converted.
+func (s *Updater) conflictsWithValues(oldObject, newObject *typed.TypedValue, sets fieldpath.ManagedFields) Conflicts {
+	conflicts := ConflictsFromManagers(sets)
+	objects := map[fieldpath.APIVersion][2]*typed.TypedValue{}
+	for i, c := range conflicts {
+		versioned, ok := objects[c.APIVersion]
+		if !ok {
+			var err error
+			if versioned[0], err = s.Converter.Convert(oldObject, c.APIVersion); err != nil {
+				versioned[0] = nil
+			}
+			if versioned[1], err = s.Converter.Convert(newObject, c.APIVersion); err != nil {
+				versioned[1] = nil
+			}
+			objects[c.APIVersion] = versioned
+		}
+		conflicts[i].Current = valueAt(versioned[0], c.Path)
+		conflicts[i].Requested = valueAt(versioned[1], c.Path)
+	}
+	return conflicts
+}
+
+// valueAt returns a copy of the value at path in tv, or nil if there is
+// none. Values are informative, so paths that can't be resolved are ignored.
+func valueAt(tv *typed.TypedValue, path fieldpath.Path) value.Value {
+	if tv == nil {
+		return nil
+	}
+	v, ok, err := tv.Get(path)
+	if err != nil || !ok || v == nil {
+		return nil
+	}
+	return value.NewValueInterface(toUnstructured(v))
+}
+
+// splitForced splits conflicts between the ones on fields in forced,
+// given at version along with everything under them, and the others.
+func (s *Updater) splitForced(conflicts fieldpath.ManagedFields, forced *fieldpath.Set, version fieldpath.APIVersion, oldObject, newObject *typed.TypedValue) (taken, remaining fieldpath.ManagedFields, err error) {
+	taken = fieldpath.ManagedFields{}
+	remaining = fieldpath.ManagedFields{}
+	versions := map[fieldpath.APIVersion]*fieldpath.Set{version: forced}
+	for manager, conflictSet := range conflicts {
+		forcedAt, ok := versions[conflictSet.APIVersion()]
+		if !ok {
+			forcedAt, err = s.convertFields(forced, version, conflictSet.APIVersion(), oldObject, newObject)
+			if err != nil {
+				return nil, nil, err
+			}
+			versions[conflictSet.APIVersion()] = forcedAt
+		}
+		forcedSet := fieldpath.NewSet()
+		otherSet := fieldpath.NewSet()
+		conflictSet.Set().Iterate(func(p fieldpath.Path) {
+			if hasPrefix(forcedAt, p) {
+				forcedSet.Insert(p)
+			} else {
+				otherSet.Insert(p)
+			}
+		})
+		if !forcedSet.Empty() {
+			taken[manager] = fieldpath.NewVersionedSet(forcedSet, conflictSet.APIVersion(), conflictSet.Applied())
+		}
+		if !otherSet.Empty() {
+			remaining[manager] = fieldpath.NewVersionedSet(otherSet, conflictSet.APIVersion(), conflictSet.Applied())
+		}
+	}
+	return taken, remaining, nil
+}
+
+// unforcedConflicts returns the conflicts that aren't on the fields in
+// forced, given at version, as an error, if there are any. None of the
+// conflicts are forced if forced is nil.
+func (s *Updater) unforcedConflicts(conflicts fieldpath.ManagedFields, forced *fieldpath.Set, version fieldpath.APIVersion, oldObject, newObject *typed.TypedValue) error {
+	remaining := conflicts
+	if forced != nil {
+		var err error
+		if _, remaining, err = s.splitForced(conflicts, forced, version, oldObject, newObject); err != nil {
+			return err
+		}
+	}
+	if len(remaining) != 0 {
+		return s.conflictsWithValues(oldObject, newObject, remaining)
+	}
+	return nil
+}
+
+// convertFields converts a set of fields from one version to another.
+// Without a SetConverter, the parts of the objects with these fields are
+// converted instead. The objects must be at version from.
+func (s *Updater) convertFields(set *fieldpath.Set, from, to fieldpath.APIVersion, objects ...*typed.TypedValue) (*fieldpath.Set, error) {
+	if sc, ok := s.Converter.(SetConverter); ok {
+		converted, err := sc.ConvertSet(set, from, to)
+		if err != nil {
+			if s.Converter.IsMissingVersionError(err) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to convert fields: %v", err)
+		}
+		return converted, nil
+	}
+	out := fieldpath.NewSet()
+	for _, object := range objects {
+		extracted, err := object.ExtractItems(set)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract fields: %v", err)
+		}
+		convertedSet, err := s.convertedFieldSet(extracted, to)
+		if err != nil {
+			return nil, err
+		}
+		// The extracted object also has the list items, and their key
+		// fields, that the fields are under. They aren't part of the set,
+		// so their converted counterparts are left out.
+		extractedSet, err := extracted.ToFieldSet()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get extracted fields: %v", err)
+		}
+		if added := withoutRequestedItems(extractedSet.Difference(set), set); !added.Empty() {
+			addedObject, err := object.ExtractItems(added)
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract fields: %v", err)
+			}
+			addedSet, err := s.convertedFieldSet(addedObject, to)
+			if err != nil {
+				return nil, err
+			}
+			convertedSet = convertedSet.Difference(addedSet)
+		}
+		out = out.Union(convertedSet)
+	}
+	return out, nil
+}
+
+// convertedFieldSet returns the fields of object once converted to version.
+func (s *Updater) convertedFieldSet(object *typed.TypedValue, version fieldpath.APIVersion) (*fieldpath.Set, error) {
+	converted, err := s.Converter.Convert(object, version)
+	if err != nil {
+		if s.Converter.IsMissingVersionError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to convert fields: %v", err)
+	}
+	convertedSet, err := converted.ToFieldSet()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get converted fields: %v", err)
+	}
+	return convertedSet, nil
+}
+
+// withoutRequestedItems returns the list items of added, and their key
+// fields, except for the items that are in set or have key fields in set.
+// These are converted along with the key fields, which can't be told
+// apart from the item once converted, so the key fields of an item in
+// set are kept.
+func withoutRequestedItems(added, set *fieldpath.Set) *fieldpath.Set {
+	out := fieldpath.NewSet()
+	added.Iterate(func(p fieldpath.Path) {
+		item := p
+		if len(p) > 0 && p[len(p)-1].FieldName != nil {
+			item = p[:len(p)-1]
+		}
+		if len(item) == 0 || item[len(item)-1].Key == nil {
+			out.Insert(p)
+			return
+		}
+		if set.Has(item) {
+			return
+		}
+		for _, key := range *item[len(item)-1].Key {
+			if set.Has(append(item.Copy(), fieldpath.PathElement{FieldName: &key.Name})) {
+				return
+			}
+		}
+		out.Insert(p)
+	})
+	return out
+}
+
+// hasPrefix returns true if set has p or one of its parents.
+func hasPrefix(set *fieldpath.Set, p fieldpath.Path) bool {
+	for i := range p {
+		if set.Has(p[:i+1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -17,9 +17,11 @@ limitations under the License.
 package merge_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
 	"sigs.k8s.io/structured-merge-diff/v4/merge"
 	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
@@ -92,3 +94,58 @@ func TestToSet(t *testing.T) {
 		t.Fatalf("expected\n%v\n, but got\n%v\n", expected, actual)
 	}
 }
+
+func TestConflictDetails(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{Converter: renamingConverter{structMultiversionParser}},
+		Parser:  structMultiversionParser,
+	}
+	if err := state.Apply(`
+		struct:
+		  name: a
+		  scalarField_v1: a
+	`, "v1", "apply-one", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	err := state.Apply(`
+		struct:
+		  name: a
+		  scalarField_v2: b
+	`, "v2", "apply-two", false)
+	conflicts, ok := err.(merge.Conflicts)
+	if !ok || len(conflicts) != 1 {
+		t.Fatalf("expected one conflict, got: %v", err)
+	}
+
+	// The conflict is given in the version of the manager that owns the field.
+	c := conflicts[0]
+	if c.Manager != "apply-one" || !c.Path.Equals(_P("struct", "scalarField_v1")) {
+		t.Errorf("unexpected conflict: %v", c)
+	}
+	if c.APIVersion != "v1" || !c.Applied {
+		t.Errorf("expected the field to be applied at v1, got %v (applied: %v)", c.APIVersion, c.Applied)
+	}
+	if c.Current == nil || !value.Equals(c.Current, _V("a")) {
+		t.Errorf("expected current value %q, got %v", "a", c.Current)
+	}
+	if c.Requested == nil || !value.Equals(c.Requested, _V("b")) {
+		t.Errorf("expected requested value %q, got %v", "b", c.Requested)
+	}
+
+	data, err := json.Marshal(conflicts)
+	if err != nil {
+		t.Fatalf("failed to marshal conflicts: %v", err)
+	}
+	expected := `[{"manager":"apply-one","field":".struct.scalarField_v1","path":["f:struct","f:scalarField_v1"],"apiVersion":"v1","applied":true,"current":"a","requested":"b"}]`
+	if string(data) != expected {
+		t.Errorf("expected JSON:\n%v\ngot:\n%v", expected, string(data))
+	}
+	var decoded merge.Conflicts
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal conflicts: %v", err)
+	}
+	if !decoded.Equals(conflicts) || decoded[0].APIVersion != c.APIVersion || decoded[0].Applied != c.Applied ||
+		!value.Equals(decoded[0].Current, c.Current) || !value.Equals(decoded[0].Requested, c.Requested) {
+		t.Errorf("expected decoded conflicts to be %#v, got %#v", conflicts, decoded)
+	}
+}
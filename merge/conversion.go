@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// conversionCache is a Converter that remembers the conversions made by
+// another Converter, so that converting the same object to the same
+// version twice only converts it once. Objects are identified by their
+// address, so a cache must only be used for a single operation, during
+// which the objects aren't modified.
+type conversionCache struct {
+	converter   Converter
+	conversions map[conversionKey]conversion
+}
+
+type conversionKey struct {
+	object  *typed.TypedValue
+	version fieldpath.APIVersion
+}
+
+type conversion struct {
+	object *typed.TypedValue
+	err    error
+}
+
+var _ Converter = &conversionCache{}
+
+// setConversionCache is a conversionCache for a Converter that is also a
+// SetConverter. Sets are converted directly, without caching.
+type setConversionCache struct {
+	*conversionCache
+	SetConverter
+}
+
+// newConversionCache returns a caching Converter for converter, which is
+// also a SetConverter if converter is one.
+func newConversionCache(converter Converter) Converter {
+	c := &conversionCache{
+		converter:   converter,
+		conversions: map[conversionKey]conversion{},
+	}
+	if sc, ok := converter.(SetConverter); ok {
+		return setConversionCache{conversionCache: c, SetConverter: sc}
+	}
+	return c
+}
+
+// Convert implements Converter.
+func (c *conversionCache) Convert(object *typed.TypedValue, version fieldpath.APIVersion) (*typed.TypedValue, error) {
+	key := conversionKey{object: object, version: version}
+	if result, ok := c.conversions[key]; ok {
+		return result.object, result.err
+	}
+	converted, err := c.converter.Convert(object, version)
+	c.conversions[key] = conversion{object: converted, err: err}
+	return converted, err
+}
+
+// IsMissingVersionError implements Converter.
+func (c *conversionCache) IsMissingVersionError(err error) bool {
+	return c.converter.IsMissingVersionError(err)
+}
+
+// withConversionCache returns a copy of the Updater that converts each
+// object to each version only once, for the duration of one operation.
+func (s *Updater) withConversionCache() *Updater {
+	cached := *s
+	cached.Converter = newConversionCache(s.Converter)
+	return &cached
+}
+
+// convertOwnedFields converts fields owned at version from to version to,
+// using liveObject if the Converter isn't a SetConverter.
+func (s *Updater) convertOwnedFields(liveObject *typed.TypedValue, fields *fieldpath.Set, from, to fieldpath.APIVersion) (*fieldpath.Set, error) {
+	if from == to {
+		return fields, nil
+	}
+	var objects []*typed.TypedValue
+	if _, ok := s.Converter.(SetConverter); !ok {
+		object, err := s.Converter.Convert(liveObject, from)
+		if err != nil {
+			if s.Converter.IsMissingVersionError(err) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to convert live object to %v: %v", from, err)
+		}
+		objects = append(objects, object)
+	}
+	return s.convertFields(fields, from, to, objects...)
+}
+
+// ConvertManagedFields returns managers with the fields of each manager
+// converted to version, using liveObject if the Converter isn't a
+// SetConverter. The fields of all managers can then be compared with each
+// other, or with those of liveObject, as with ManagedFields.OwnersOf.
+// Managers at a version that doesn't exist anymore are left out, and
+// their contested and deleted fields aren't kept, unlike the rest of
+// their metadata.
+func (s *Updater) ConvertManagedFields(liveObject *typed.TypedValue, managers fieldpath.ManagedFields, version fieldpath.APIVersion) (fieldpath.ManagedFields, error) {
+	s = s.withConversionCache()
+	converted := fieldpath.ManagedFields{}
+	for manager, set := range managers {
+		if set.APIVersion() == version {
+			converted[manager] = set
+			continue
+		}
+		fields, err := s.convertOwnedFields(liveObject, set.Set(), set.APIVersion(), version)
+		if err != nil {
+			if s.Converter.IsMissingVersionError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to convert fields of %q: %v", manager, err)
+		}
+		// The contested and deleted fields are at the previous version.
+		metadata := fieldpath.MetadataOf(set)
+		metadata.Contested, metadata.Deleted = nil, nil
+		converted[manager] = fieldpath.NewVersionedSetWithMetadata(fields, version, set.Applied(), metadata)
+	}
+	return converted, nil
+}
+
+// Unowned returns the fields of liveObject, at version, that no manager
+// owns, either directly or through a field they are under. The fields of
+// the managers are converted to version first, as with
+// ConvertManagedFields.
+func (s *Updater) Unowned(liveObject *typed.TypedValue, managers fieldpath.ManagedFields, version fieldpath.APIVersion) (*fieldpath.Set, error) {
+	converted, err := s.ConvertManagedFields(liveObject, managers, version)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := liveObject.ToFieldSet()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get live fields: %v", err)
+	}
+	return converted.Unowned(fields, version)
+}
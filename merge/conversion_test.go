@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// countingConverter counts how many times each object is converted to
+// each version.
+type countingConverter struct {
+	merge.Converter
+	counts map[countingKey]int
+}
+
+type countingKey struct {
+	object  *typed.TypedValue
+	version fieldpath.APIVersion
+}
+
+// Convert implements merge.Converter
+func (c *countingConverter) Convert(v *typed.TypedValue, version fieldpath.APIVersion) (*typed.TypedValue, error) {
+	c.counts[countingKey{object: v, version: version}]++
+	return c.Converter.Convert(v, version)
+}
+
+func TestConversionsAreCached(t *testing.T) {
+	converter := &countingConverter{
+		Converter: repeatingConverter{nestedTypeParser},
+		counts:    map[countingKey]int{},
+	}
+	updater := &merge.Updater{Converter: converter}
+
+	managers := fieldpath.ManagedFields{}
+	live, err := nestedTypeParser.Type("v1").FromYAML(`{}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []struct {
+		manager string
+		version fieldpath.APIVersion
+		object  typed.YAMLObject
+	}{
+		{"apply-one", "v1", `{"mapOfMapsRecursive": {"a": {"b": null}}}`},
+		{"apply-two", "v2", `{"mapOfMapsRecursive": {"cc": null}}`},
+		{"apply-three", "v3", `{"mapOfMapsRecursive": {"ddd": null}}`},
+		{"apply-one", "v4", `{"mapOfMapsRecursive": {"eeee": null}}`},
+	} {
+		config, err := nestedTypeParser.Type(string(op.version)).FromYAML(op.object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if live, err = converter.Converter.Convert(live, op.version); err != nil {
+			t.Fatal(err)
+		}
+		converter.counts = map[countingKey]int{}
+		if live, managers, err = updater.Apply(live, config, op.version, managers, op.manager, false); err != nil {
+			t.Fatalf("failed to apply as %v: %v", op.manager, err)
+		}
+		for key, count := range converter.counts {
+			if count > 1 {
+				t.Errorf("applying as %v converted the same object to %v %d times", op.manager, key.version, count)
+			}
+		}
+	}
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+)
+
+func TestApplyDeletions(t *testing.T) {
+	tests := map[string]TestCase{
+		"delete_updated_item_with_force": {
+			Ops: []Operation{
+				Update{
+					Manager: "controller",
+					Object: `
+						list:
+						- name: a
+						  value: 1
+						- name: b
+						  value: 2
+					`,
+					APIVersion: "v1",
+				},
+				ForceApply{
+					Manager: "default",
+					Object: `
+						list:
+						- name: c
+						  value: 3
+					`,
+					Deletions:  _NS(_P("list", _KBF("name", "a"))),
+					APIVersion: "v1",
+				},
+			},
+			Object: `
+				list:
+				- name: b
+				  value: 2
+				- name: c
+				  value: 3
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"controller": fieldpath.NewVersionedSet(
+					_NS(
+						_P("list"),
+						_P("list", _KBF("name", "b")),
+						_P("list", _KBF("name", "b"), "name"),
+						_P("list", _KBF("name", "b"), "value"),
+					),
+					"v1",
+					false,
+				),
+				"default": fieldpath.NewVersionedSetWithMetadata(
+					_NS(
+						_P("list", _KBF("name", "c")),
+						_P("list", _KBF("name", "c"), "name"),
+						_P("list", _KBF("name", "c"), "value"),
+					),
+					"v1",
+					true,
+					fieldpath.ManagerMetadata{Deleted: _NS(_P("list", _KBF("name", "a")))},
+				),
+			},
+		},
+		"delete_applied_item_conflicts": {
+			Ops: []Operation{
+				Apply{
+					Manager: "other",
+					Object: `
+						list:
+						- name: a
+						  value: 1
+					`,
+					APIVersion: "v1",
+				},
+				Apply{
+					Manager: "default",
+					Object: `
+						list:
+						- name: b
+						  value: 2
+					`,
+					Deletions:  _NS(_P("list", _KBF("name", "a"))),
+					APIVersion: "v1",
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "other", Path: _P("list", _KBF("name", "a"))},
+						merge.Conflict{Manager: "other", Path: _P("list", _KBF("name", "a"), "name")},
+						merge.Conflict{Manager: "other", Path: _P("list", _KBF("name", "a"), "value")},
+					},
+				},
+			},
+			Object: `
+				list:
+				- name: a
+				  value: 1
+			`,
+			APIVersion: "v1",
+		},
+		"delete_field_of_item": {
+			Ops: []Operation{
+				Update{
+					Manager: "controller",
+					Object: `
+						list:
+						- name: a
+						  value: 1
+					`,
+					APIVersion: "v1",
+				},
+				ForceApply{
+					Manager: "default",
+					Object: `
+						list:
+						- name: a
+					`,
+					Deletions:  _NS(_P("list", _KBF("name", "a"), "value")),
+					APIVersion: "v1",
+				},
+			},
+			Object: `
+				list:
+				- name: a
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"controller": fieldpath.NewVersionedSet(
+					_NS(
+						_P("list"),
+						_P("list", _KBF("name", "a")),
+						_P("list", _KBF("name", "a"), "name"),
+					),
+					"v1",
+					false,
+				),
+				"default": fieldpath.NewVersionedSetWithMetadata(
+					_NS(
+						_P("list", _KBF("name", "a")),
+						_P("list", _KBF("name", "a"), "name"),
+					),
+					"v1",
+					true,
+					fieldpath.ManagerMetadata{Deleted: _NS(_P("list", _KBF("name", "a"), "value"))},
+				),
+			},
+		},
+		"delete_and_force_fields": {
+			Ops: []Operation{
+				Apply{
+					Manager: "other",
+					Object: `
+						list:
+						- name: a
+						  value: 1
+						- name: b
+						  value: 2
+					`,
+					APIVersion: "v1",
+				},
+				Apply{
+					Manager: "default",
+					Object: `
+						list:
+						- name: b
+						  value: 3
+					`,
+					Deletions:  _NS(_P("list", _KBF("name", "a"))),
+					Forced:     _NS(_P("list", _KBF("name", "a")), _P("list", _KBF("name", "b"), "value")),
+					APIVersion: "v1",
+					Conflicts:  merge.Conflicts{},
+				},
+			},
+			Object: `
+				list:
+				- name: b
+				  value: 3
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"other": fieldpath.NewVersionedSet(
+					_NS(
+						_P("list", _KBF("name", "b")),
+						_P("list", _KBF("name", "b"), "name"),
+					),
+					"v1",
+					true,
+				),
+				"default": fieldpath.NewVersionedSetWithMetadata(
+					_NS(
+						_P("list", _KBF("name", "b")),
+						_P("list", _KBF("name", "b"), "name"),
+						_P("list", _KBF("name", "b"), "value"),
+					),
+					"v1",
+					true,
+					fieldpath.ManagerMetadata{Deleted: _NS(_P("list", _KBF("name", "a")))},
+				),
+			},
+		},
+		"delete_missing_item": {
+			Ops: []Operation{
+				Apply{
+					Manager: "default",
+					Object: `
+						list:
+						- name: a
+						  value: 1
+					`,
+					Deletions:  _NS(_P("list", _KBF("name", "b"))),
+					APIVersion: "v1",
+				},
+			},
+			Object: `
+				list:
+				- name: a
+				  value: 1
+			`,
+			APIVersion: "v1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.Test(associativeListParser); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestApplyAndDeleteSameField(t *testing.T) {
+	test := TestCase{
+		Ops: []Operation{
+			Apply{
+				Manager: "default",
+				Object: `
+					list:
+					- name: a
+					  value: 1
+				`,
+				Deletions:  _NS(_P("list", _KBF("name", "a"))),
+				APIVersion: "v1",
+			},
+		},
+	}
+	if err := test.Test(associativeListParser); err == nil {
+		t.Fatal("expected applying and deleting the same field to fail")
+	}
+}
+
+func TestUpdateWithApplyOptions(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{Converter: renamingConverter{associativeListParser}},
+		Parser:  associativeListParser,
+	}
+	tv, err := associativeListParser.Type("v1").FromYAML(`list: [{name: a, value: 1}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, options := range map[string]merge.Options{
+		"forced":    {Forced: _NS(_P("list"))},
+		"deletions": {Deletions: _NS(_P("list"))},
+		"plan":      {Plan: &merge.ApplyPlan{}},
+	} {
+		if err := state.UpdateObjectWithOptions(tv, "v1", "controller", options); err == nil {
+			t.Errorf("expected updating with %v to fail", name)
+		}
+	}
+}
+
+func TestApplyDeletionsWithUnionNormalization(t *testing.T) {
+	test := TestCase{
+		Ops: []Operation{
+			Update{
+				Manager:    "controller",
+				APIVersion: "v1",
+				Object: `
+					numeric: 1
+					fieldA: a
+				`,
+			},
+			// Normalizing the union removes fieldA, which isn't under the
+			// deleted fields and so isn't a conflict.
+			Apply{
+				Manager:    "default",
+				APIVersion: "v1",
+				Object: `
+					fieldB: b
+				`,
+				Deletions: _NS(_P("numeric")),
+				Forced:    _NS(_P("numeric")),
+			},
+		},
+		Object: `
+			type: Numeric
+			fieldB: b
+		`,
+		APIVersion: "v1",
+		Managed: fieldpath.ManagedFields{
+			"controller": fieldpath.NewVersionedSet(
+				_NS(_P("type")),
+				"v1",
+				false,
+			),
+			"default": fieldpath.NewVersionedSetWithMetadata(
+				_NS(_P("fieldB")),
+				"v1",
+				true,
+				fieldpath.ManagerMetadata{Deleted: _NS(_P("numeric"))},
+			),
+		},
+	}
+	if err := test.Test(unionFieldsParser); err != nil {
+		t.Fatal(err)
+	}
+}
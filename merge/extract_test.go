@@ -0,0 +1,128 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+func TestExtractApplied(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{Converter: renamingConverter{structMultiversionParser}},
+		Parser:  structMultiversionParser,
+	}
+	if err := state.Apply(`
+		struct:
+		  name: a
+		  scalarField_v1: a
+	`, "v1", "apply-one", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	if err := state.Apply(`
+		struct:
+		  complexField_v2:
+		    name: b
+	`, "v2", "apply-two", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	if err := state.Update(`
+		struct:
+		  name: a
+		  scalarField_v3: a
+		  complexField_v3:
+		    name: b
+		version: c
+	`, "v3", "controller"); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+
+	tests := map[string]struct {
+		manager  string
+		version  fieldpath.APIVersion
+		expected typed.YAMLObject
+	}{
+		"apply-one": {
+			manager: "apply-one",
+			version: "v1",
+			expected: `
+				struct:
+				  name: a
+				  scalarField_v1: a
+			`,
+		},
+		"apply-two": {
+			manager: "apply-two",
+			version: "v2",
+			expected: `
+				struct:
+				  complexField_v2:
+				    name: b
+			`,
+		},
+		"controller": {
+			manager: "controller",
+			version: "v3",
+			expected: `
+				version: c
+			`,
+		},
+		"unknown": {
+			manager:  "unknown",
+			version:  "v3",
+			expected: `{}`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			extracted, err := state.Updater.ExtractApplied(state.Live, state.Managers, test.manager)
+			if err != nil {
+				t.Fatalf("failed to extract: %v", err)
+			}
+			expected, err := structMultiversionParser.Type(string(test.version)).FromYAML(FixTabsOrDie(test.expected))
+			if err != nil {
+				t.Fatalf("failed to parse expected object: %v", err)
+			}
+			comparison, err := extracted.Compare(expected)
+			if err != nil {
+				t.Fatalf("failed to compare: %v", err)
+			}
+			if !comparison.IsSame() {
+				t.Errorf("unexpected extracted object:\n%v", comparison)
+			}
+		})
+	}
+}
+
+func TestExtractAppliedMissingVersion(t *testing.T) {
+	updater := &merge.Updater{Converter: repeatingConverter{nestedTypeParser}}
+	live, err := nestedTypeParser.Type("v1").FromYAML(`{"struct": {"name": "a"}}`)
+	if err != nil {
+		t.Fatalf("failed to parse object: %v", err)
+	}
+	managers := fieldpath.ManagedFields{
+		"apply": fieldpath.NewVersionedSet(_NS(_P("struct", "name")), "obsolete", true),
+	}
+	// The error isn't wrapped, so that it can be recognized.
+	if _, err := updater.ExtractApplied(live, managers, "apply"); err != missingVersionError {
+		t.Fatalf("expected a missing version error, got %v", err)
+	}
+}
@@ -0,0 +1,369 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+func TestApplyForcingFields(t *testing.T) {
+	tests := map[string]TestCase{
+		"force_selected_field": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "a"
+					`,
+				},
+				Update{
+					Manager:    "autoscaler",
+					APIVersion: "v1",
+					Object: `
+						numeric: 3
+						string: "a"
+					`,
+				},
+				Apply{
+					Manager:    "rollout",
+					APIVersion: "v1",
+					Object: `
+						numeric: 5
+					`,
+					Forced:    _NS(_P("numeric")),
+					Conflicts: merge.Conflicts{},
+				},
+			},
+			Object: `
+				numeric: 5
+				string: "a"
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(_P("string")),
+					"v1",
+					true,
+				),
+				"rollout": fieldpath.NewVersionedSet(
+					_NS(_P("numeric")),
+					"v1",
+					true,
+				),
+			},
+		},
+		"other_conflicts_remain": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "a"
+					`,
+				},
+				Update{
+					Manager:    "autoscaler",
+					APIVersion: "v1",
+					Object: `
+						numeric: 3
+						string: "a"
+					`,
+				},
+				Apply{
+					Manager:    "rollout",
+					APIVersion: "v1",
+					Object: `
+						numeric: 5
+						string: "b"
+					`,
+					Forced: _NS(_P("numeric")),
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "apply-one", Path: _P("string")},
+					},
+				},
+			},
+			Object: `
+				numeric: 3
+				string: "a"
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(_P("string")),
+					"v1",
+					true,
+				),
+				"autoscaler": fieldpath.NewVersionedSet(
+					_NS(_P("numeric")),
+					"v1",
+					false,
+				),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.Test(leafFieldsParser); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestApplyForcingFieldsMultipleVersions(t *testing.T) {
+	tests := map[string]TestCase{
+		"force_field_of_other_version": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						struct:
+						  name: a
+						  scalarField_v1: a
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v2",
+					Object: `
+						struct:
+						  name: a
+						  scalarField_v2: b
+					`,
+					Forced:    _NS(_P("struct", "scalarField_v2")),
+					Conflicts: merge.Conflicts{},
+				},
+			},
+			Object: `
+				struct:
+				  name: a
+				  scalarField_v1: b
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(_P("struct", "name")),
+					"v1",
+					true,
+				),
+				"apply-two": fieldpath.NewVersionedSet(
+					_NS(_P("struct", "name"), _P("struct", "scalarField_v2")),
+					"v2",
+					true,
+				),
+			},
+		},
+		"force_other_field": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						struct:
+						  name: a
+						  scalarField_v1: a
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v2",
+					Object: `
+						struct:
+						  name: a
+						  scalarField_v2: b
+					`,
+					Forced: _NS(_P("struct", "name")),
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "apply-one", Path: _P("struct", "scalarField_v1")},
+					},
+				},
+			},
+		},
+	}
+
+	converter := renamingConverter{structMultiversionParser}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.TestWithConverter(structMultiversionParser, converter); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+var keyedListMultiversionParser = func() Parser {
+	parser, err := typed.NewParser(`types:
+- name: v1
+  map:
+    fields:
+      - name: list
+        type:
+          list:
+            elementType:
+              namedType: item_v1
+            elementRelationship: associative
+            keys:
+            - name
+- name: item_v1
+  map:
+    fields:
+      - name: name
+        type:
+          scalar: string
+      - name: value_v1
+        type:
+          scalar: string
+      - name: other_v1
+        type:
+          scalar: string
+- name: v2
+  map:
+    fields:
+      - name: list
+        type:
+          list:
+            elementType:
+              namedType: item_v2
+            elementRelationship: associative
+            keys:
+            - name
+- name: item_v2
+  map:
+    fields:
+      - name: name
+        type:
+          scalar: string
+      - name: value_v2
+        type:
+          scalar: string
+      - name: other_v2
+        type:
+          scalar: string
+`)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}()
+
+// TestApplyForcingItemFieldsWithoutSetConverter forces fields of list items
+// across versions with a Converter that can only convert objects: the
+// forced fields are converted along with their item, which must not be
+// forced as a whole.
+func TestApplyForcingItemFieldsWithoutSetConverter(t *testing.T) {
+	tests := map[string]TestCase{
+		"force_item_field": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						list:
+						- name: a
+						  value_v1: x
+						  other_v1: o
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v2",
+					Object: `
+						list:
+						- name: a
+						  value_v2: z
+					`,
+					Forced:    _NS(_P("list", _KBF("name", "a"), "value_v2")),
+					Conflicts: merge.Conflicts{},
+				},
+			},
+			Object: `
+				list:
+				- name: a
+				  value_v1: z
+				  other_v1: o
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(
+						_P("list", _KBF("name", "a")),
+						_P("list", _KBF("name", "a"), "name"),
+						_P("list", _KBF("name", "a"), "other_v1"),
+					),
+					"v1",
+					true,
+				),
+				"apply-two": fieldpath.NewVersionedSet(
+					_NS(
+						_P("list", _KBF("name", "a")),
+						_P("list", _KBF("name", "a"), "name"),
+						_P("list", _KBF("name", "a"), "value_v2"),
+					),
+					"v2",
+					true,
+				),
+			},
+		},
+		"other_item_fields_conflict": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						list:
+						- name: a
+						  value_v1: x
+						  other_v1: o
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v2",
+					Object: `
+						list:
+						- name: a
+						  value_v2: z
+						  other_v2: p
+					`,
+					Forced: _NS(_P("list", _KBF("name", "a"), "value_v2")),
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "apply-one", Path: _P("list", _KBF("name", "a"), "other_v1")},
+					},
+				},
+			},
+		},
+	}
+
+	converter := renamingConverter{keyedListMultiversionParser}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.TestWithConverter(keyedListMultiversionParser, converter); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,575 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// FieldMapping describes where a field of one version is stored in
+// another version.
+//
+// From and To are paths made of field names. The part they have in
+// common is where the mapping applies: if it goes through a list, the
+// mapping applies to every item of the list. The rest of From is where
+// the field is in the source version, and the rest of To where it is in
+// the target version, which can be a rename, or a move into or out of a
+// nested struct. The rest of From can't go through a list.
+type FieldMapping struct {
+	From []string
+	To   []string
+
+	// Values maps the string values of the field, or of the items of a
+	// list field, from the source version to the target version. This
+	// is typically used for enums. Values that aren't listed are kept.
+	Values map[string]string
+
+	// ListKey, if set, means that the field is a list of structs in the
+	// source version and a map in the target version. Every item of the
+	// list is stored in the map under the value of its ListKey field,
+	// which must be a string, and without that field.
+	ListKey string
+}
+
+// VersionMapping lists the fields that have changed between two
+// versions. Fields that aren't listed are the same in both versions, so
+// a mapping without fields is for versions whose types have the same
+// fields. The types are still distinct, since the version of an object
+// is found from its type (see NewMappingConverter). The mapping is also
+// used to convert from To to From.
+type VersionMapping struct {
+	From   fieldpath.APIVersion
+	To     fieldpath.APIVersion
+	Fields []FieldMapping
+}
+
+// MappingConverter is a Converter that converts objects, and sets of
+// fields, between versions according to a list of VersionMapping.
+// Versions without a mapping between them are converted through other
+// versions, chaining the fewest mappings: with mappings from v1 to v2 and
+// from v2 to v3, v1 is converted to v3 through v2. Versions that no
+// mappings connect can't be converted to each other, which is an error.
+// Unlike for unknown versions, this isn't a missing version error (see
+// IsMissingVersionError), since the Updater would drop the managers of
+// these versions rather than fail.
+type MappingConverter struct {
+	types    map[fieldpath.APIVersion]typed.ParseableType
+	mappings map[versionPair][]fieldMapping
+}
+
+var _ Converter = &MappingConverter{}
+var _ SetConverter = &MappingConverter{}
+
+type versionPair struct {
+	from, to fieldpath.APIVersion
+}
+
+// fieldMapping is a FieldMapping, split in the part that's in common
+// between From and To and the rest, in either direction.
+type fieldMapping struct {
+	prefix, from, to []string
+	values           map[string]string
+	listKey          string
+	// mapToList is set if the mapping converts from a map to a list,
+	// i.e. it is the inverse of a FieldMapping with a ListKey.
+	mapToList bool
+}
+
+// NewMappingConverter returns a converter between the given types, one for
+// each version, using the given mappings. Every version must have its own
+// type, since the version of an object is found from its type.
+func NewMappingConverter(types map[fieldpath.APIVersion]typed.ParseableType, mappings ...VersionMapping) (*MappingConverter, error) {
+	c := &MappingConverter{
+		types:    types,
+		mappings: map[versionPair][]fieldMapping{},
+	}
+	for v1, t1 := range types {
+		for v2, t2 := range types {
+			if v1 < v2 && t1.TypeRef.Equals(&t2.TypeRef) && t1.Schema.Equals(t2.Schema) {
+				return nil, fmt.Errorf("versions %q and %q have the same type", v1, v2)
+			}
+		}
+	}
+	for _, vm := range mappings {
+		if _, ok := types[vm.From]; !ok {
+			return nil, fmt.Errorf("mapping from unknown version %q", vm.From)
+		}
+		if _, ok := types[vm.To]; !ok {
+			return nil, fmt.Errorf("mapping to unknown version %q", vm.To)
+		}
+		forward := versionPair{from: vm.From, to: vm.To}
+		backward := versionPair{from: vm.To, to: vm.From}
+		if _, ok := c.mappings[forward]; ok {
+			return nil, fmt.Errorf("duplicate mapping between %q and %q", vm.From, vm.To)
+		}
+		c.mappings[forward] = nil
+		if _, ok := c.mappings[backward]; !ok {
+			c.mappings[backward] = nil
+		}
+		for _, f := range vm.Fields {
+			if len(f.From) == 0 || len(f.To) == 0 {
+				return nil, fmt.Errorf("mapping between %q and %q has an empty path", vm.From, vm.To)
+			}
+			m, err := newFieldMapping(f)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mapping between %q and %q: %v", vm.From, vm.To, err)
+			}
+			c.mappings[forward] = append(c.mappings[forward], m)
+			// The inverse mappings are applied in the reverse order.
+			c.mappings[backward] = append([]fieldMapping{m.inverse()}, c.mappings[backward]...)
+		}
+	}
+	c.chainMappings()
+	return c, nil
+}
+
+// chainMappings adds the mappings between the versions that are only
+// connected through other versions, chaining the mappings along the
+// shortest path between them.
+func (c *MappingConverter) chainMappings() {
+	neighbors := map[fieldpath.APIVersion][]fieldpath.APIVersion{}
+	for pair := range c.mappings {
+		neighbors[pair.from] = append(neighbors[pair.from], pair.to)
+	}
+	for _, next := range neighbors {
+		sort.Slice(next, func(i, j int) bool { return next[i] < next[j] })
+	}
+	chained := map[versionPair][]fieldMapping{}
+	for from := range c.types {
+		// Breadth-first, so that the first chain found to a version
+		// is one of the shortest.
+		found := map[fieldpath.APIVersion][]fieldMapping{from: nil}
+		queue := []fieldpath.APIVersion{from}
+		for len(queue) > 0 {
+			version := queue[0]
+			queue = queue[1:]
+			for _, next := range neighbors[version] {
+				if _, ok := found[next]; ok {
+					continue
+				}
+				mappings := append(append([]fieldMapping{}, found[version]...), c.mappings[versionPair{from: version, to: next}]...)
+				found[next] = mappings
+				chained[versionPair{from: from, to: next}] = mappings
+				queue = append(queue, next)
+			}
+		}
+	}
+	c.mappings = chained
+}
+
+func newFieldMapping(f FieldMapping) (fieldMapping, error) {
+	n := 0
+	for n < len(f.From)-1 && n < len(f.To)-1 && f.From[n] == f.To[n] {
+		n++
+	}
+	m := fieldMapping{
+		prefix:  f.From[:n],
+		from:    f.From[n:],
+		to:      f.To[n:],
+		values:  f.Values,
+		listKey: f.ListKey,
+	}
+	if m.values != nil && m.listKey != "" {
+		return fieldMapping{}, fmt.Errorf("field %v can't both map values and have a list key", f.From)
+	}
+	return m, nil
+}
+
+func (m fieldMapping) inverse() fieldMapping {
+	inv := fieldMapping{
+		prefix:    m.prefix,
+		from:      m.to,
+		to:        m.from,
+		listKey:   m.listKey,
+		mapToList: m.listKey != "" && !m.mapToList,
+	}
+	if m.values != nil {
+		inv.values = make(map[string]string, len(m.values))
+		for k, v := range m.values {
+			inv.values[v] = k
+		}
+	}
+	return inv
+}
+
+// missingVersionError is returned for versions the converter doesn't
+// know about.
+type missingVersionError struct {
+	version fieldpath.APIVersion
+}
+
+func (e missingVersionError) Error() string {
+	return fmt.Sprintf("unknown version %q", e.version)
+}
+
+// IsMissingVersionError implements Converter.
+func (c *MappingConverter) IsMissingVersionError(err error) bool {
+	_, ok := err.(missingVersionError)
+	return ok
+}
+
+// mappingsBetween returns the mappings that convert from a version to
+// another.
+func (c *MappingConverter) mappingsBetween(from, to fieldpath.APIVersion) ([]fieldMapping, error) {
+	if _, ok := c.types[from]; !ok {
+		return nil, missingVersionError{version: from}
+	}
+	if _, ok := c.types[to]; !ok {
+		return nil, missingVersionError{version: to}
+	}
+	if from == to {
+		return nil, nil
+	}
+	mappings, ok := c.mappings[versionPair{from: from, to: to}]
+	if !ok {
+		return nil, fmt.Errorf("no mappings between versions %q and %q", from, to)
+	}
+	return mappings, nil
+}
+
+// version returns the version whose type is the type of tv.
+func (c *MappingConverter) version(tv *typed.TypedValue) (fieldpath.APIVersion, error) {
+	tr := tv.TypeRef()
+	for version, pt := range c.types {
+		if pt.TypeRef.Equals(&tr) && (pt.Schema == tv.Schema() || pt.Schema.Equals(tv.Schema())) {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("object has a type that doesn't match any version: %v", tr)
+}
+
+// Convert implements Converter.
+func (c *MappingConverter) Convert(object *typed.TypedValue, version fieldpath.APIVersion) (*typed.TypedValue, error) {
+	pt, ok := c.types[version]
+	if !ok {
+		return nil, missingVersionError{version: version}
+	}
+	from, err := c.version(object)
+	if err != nil {
+		return nil, err
+	}
+	if from == version {
+		return object, nil
+	}
+	mappings, err := c.mappingsBetween(from, version)
+	if err != nil {
+		return nil, err
+	}
+	out := toUnstructured(object.AsValue())
+	for _, m := range mappings {
+		if err := m.apply(out); err != nil {
+			return nil, fmt.Errorf("failed to convert from %q to %q: %v", from, version, err)
+		}
+	}
+	return pt.FromUnstructured(out)
+}
+
+// ConvertSet converts a set of fields of the version from to the same
+// fields in the version to.
+func (c *MappingConverter) ConvertSet(set *fieldpath.Set, from, to fieldpath.APIVersion) (*fieldpath.Set, error) {
+	mappings, err := c.mappingsBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mappings {
+		out := fieldpath.NewSet()
+		var err error
+		set.Iterate(func(p fieldpath.Path) {
+			if err != nil {
+				return
+			}
+			var paths []fieldpath.Path
+			if paths, err = m.convertPath(p); err != nil {
+				err = fmt.Errorf("failed to convert %v from %q to %q: %v", p, from, to, err)
+				return
+			}
+			for _, p := range paths {
+				out.Insert(p)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		set = out
+	}
+	return set, nil
+}
+
+// toUnstructured returns a copy of v made of maps with string keys,
+// slices and scalars, that can be modified.
+func toUnstructured(v value.Value) interface{} {
+	switch {
+	case v.IsMap():
+		m := v.AsMap()
+		out := make(map[string]interface{}, m.Length())
+		m.Iterate(func(k string, item value.Value) bool {
+			out[k] = toUnstructured(item)
+			return true
+		})
+		return out
+	case v.IsList():
+		l := v.AsList()
+		out := make([]interface{}, l.Length())
+		for i := range out {
+			out[i] = toUnstructured(l.At(i))
+		}
+		return out
+	}
+	return v.Unstructured()
+}
+
+// apply moves the field in every struct found at the prefix of the
+// mapping.
+func (m fieldMapping) apply(obj interface{}) error {
+	return forEachStruct(obj, m.prefix, func(parent map[string]interface{}) error {
+		v, ok := take(parent, m.from)
+		if !ok {
+			return nil
+		}
+		v, err := m.convertValue(v)
+		if err != nil {
+			return err
+		}
+		return put(parent, m.to, v)
+	})
+}
+
+// forEachStruct calls fn for every struct found at path, walking through
+// every item of the lists it goes through.
+func forEachStruct(v interface{}, path []string, fn func(map[string]interface{}) error) error {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if err := forEachStruct(item, path, fn); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return fn(v)
+		}
+		return forEachStruct(v[path[0]], path[1:], fn)
+	}
+	return nil
+}
+
+// take removes the field at path from m and returns it. The structs it
+// was nested in are removed too if they're left empty.
+func take(m map[string]interface{}, path []string) (interface{}, bool) {
+	v, ok := m[path[0]]
+	if !ok {
+		return nil, false
+	}
+	if len(path) == 1 {
+		delete(m, path[0])
+		return v, true
+	}
+	child, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	v, ok = take(child, path[1:])
+	if ok && len(child) == 0 {
+		delete(m, path[0])
+	}
+	return v, ok
+}
+
+// put sets the field at path in m, creating the structs it's nested in
+// as needed.
+func put(m map[string]interface{}, path []string, v interface{}) error {
+	for _, name := range path[:len(path)-1] {
+		child, ok := m[name]
+		if !ok || child == nil {
+			child = map[string]interface{}{}
+			m[name] = child
+		}
+		if m, ok = child.(map[string]interface{}); !ok {
+			return fmt.Errorf("expected %q to be a map, got %T", name, child)
+		}
+	}
+	m[path[len(path)-1]] = v
+	return nil
+}
+
+// convertValue converts the value of the field, as described by the
+// mapping.
+func (m fieldMapping) convertValue(v interface{}) (interface{}, error) {
+	switch {
+	case m.values != nil:
+		return m.convertScalars(v), nil
+	case m.listKey != "" && m.mapToList:
+		return mapToList(v, m.listKey)
+	case m.listKey != "":
+		return listToMap(v, m.listKey)
+	}
+	return v, nil
+}
+
+func (m fieldMapping) convertScalars(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		if mapped, ok := m.values[v]; ok {
+			return mapped
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = m.convertScalars(v[i])
+		}
+	}
+	return v
+}
+
+func listToMap(v interface{}, key string) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	l, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list, got %T", v)
+	}
+	out := make(map[string]interface{}, len(l))
+	for _, item := range l {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected list items to be maps, got %T", item)
+		}
+		name, ok := m[key].(string)
+		if !ok {
+			return nil, fmt.Errorf("expected list items to have a string %q field", key)
+		}
+		if _, ok := out[name]; ok {
+			return nil, fmt.Errorf("duplicate list items with %q set to %q", key, name)
+		}
+		delete(m, key)
+		out[name] = m
+	}
+	return out, nil
+}
+
+func mapToList(v interface{}, key string) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a map, got %T", v)
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]interface{}, 0, len(m))
+	for _, name := range names {
+		item, ok := m[name].(map[string]interface{})
+		if !ok {
+			if m[name] != nil {
+				return nil, fmt.Errorf("expected map values to be maps, got %T", m[name])
+			}
+			item = map[string]interface{}{}
+		}
+		item[key] = name
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// convertPath returns the paths that p is converted to. Paths that don't
+// go through the field of the mapping are unchanged.
+func (m fieldMapping) convertPath(p fieldpath.Path) ([]fieldpath.Path, error) {
+	i := 0
+	// Items of the lists the prefix goes through are skipped.
+	for _, name := range m.prefix {
+		for i < len(p) && p[i].FieldName == nil {
+			i++
+		}
+		if i == len(p) || *p[i].FieldName != name {
+			return []fieldpath.Path{p}, nil
+		}
+		i++
+	}
+	for i < len(p) && p[i].FieldName == nil {
+		i++
+	}
+	start := i
+	for _, name := range m.from {
+		if i == len(p) || p[i].FieldName == nil || *p[i].FieldName != name {
+			return []fieldpath.Path{p}, nil
+		}
+		i++
+	}
+
+	out := make(fieldpath.Path, 0, len(p)-len(m.from)+len(m.to))
+	out = append(out, p[:start]...)
+	for i := range m.to {
+		out = append(out, fieldpath.PathElement{FieldName: &m.to[i]})
+	}
+	rest := p[i:]
+	if len(rest) == 0 {
+		return []fieldpath.Path{out}, nil
+	}
+
+	switch {
+	case m.values != nil:
+		if rest[0].Value != nil && (*rest[0].Value).IsString() {
+			if mapped, ok := m.values[(*rest[0].Value).AsString()]; ok {
+				v := value.NewValueInterface(mapped)
+				rest = append(fieldpath.Path{{Value: &v}}, rest[1:]...)
+			}
+		}
+	case m.listKey != "" && m.mapToList:
+		if rest[0].FieldName == nil {
+			return nil, fmt.Errorf("expected a map key, got %v", rest[0])
+		}
+		key := value.FieldList{{Name: m.listKey, Value: value.NewValueInterface(*rest[0].FieldName)}}
+		item := append(out, fieldpath.PathElement{Key: &key})
+		if len(rest) == 1 {
+			// The key field is owned along with the item.
+			return []fieldpath.Path{item, append(item.Copy(), fieldpath.PathElement{FieldName: &m.listKey})}, nil
+		}
+		return []fieldpath.Path{append(item, rest[1:]...)}, nil
+	case m.listKey != "":
+		name, err := listKeyName(rest[0], m.listKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) > 1 && rest[1].FieldName != nil && *rest[1].FieldName == m.listKey {
+			// The key field is the key of the map entry.
+			rest = rest[:1]
+		}
+		rest = append(fieldpath.Path{{FieldName: &name}}, rest[1:]...)
+	}
+	return []fieldpath.Path{append(out, rest...)}, nil
+}
+
+// listKeyName returns the string value of the key field of a list item.
+func listKeyName(pe fieldpath.PathElement, key string) (string, error) {
+	if pe.Key != nil {
+		for _, f := range *pe.Key {
+			if f.Name == key && f.Value.IsString() {
+				return f.Value.AsString(), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("expected a list item with a string %q key, got %v", key, pe)
+}
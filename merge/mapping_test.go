@@ -0,0 +1,432 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+var mappingParser = func() Parser {
+	parser, err := typed.NewParser(`types:
+- name: v1
+  map:
+    fields:
+    - name: image
+      type:
+        scalar: string
+    - name: spec
+      type:
+        namedType: specV1
+- name: specV1
+  map:
+    fields:
+    - name: replicas
+      type:
+        scalar: numeric
+    - name: mode
+      type:
+        scalar: string
+    - name: ports
+      type:
+        list:
+          elementType:
+            namedType: portV1
+          elementRelationship: associative
+          keys:
+          - name
+- name: portV1
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: number
+      type:
+        scalar: numeric
+    - name: proto
+      type:
+        scalar: string
+- name: v2
+  map:
+    fields:
+    - name: spec
+      type:
+        namedType: specV2
+- name: specV2
+  map:
+    fields:
+    - name: size
+      type:
+        scalar: numeric
+    - name: mode
+      type:
+        scalar: string
+    - name: ports
+      type:
+        map:
+          elementType:
+            namedType: portV2
+    - name: template
+      type:
+        map:
+          fields:
+          - name: image
+            type:
+              scalar: string
+- name: portV2
+  map:
+    fields:
+    - name: number
+      type:
+        scalar: numeric
+    - name: protocol
+      type:
+        scalar: string
+- name: v3
+  map:
+    fields:
+    - name: spec
+      type:
+        namedType: specV3
+- name: specV3
+  map:
+    fields:
+    - name: count
+      type:
+        scalar: numeric
+    - name: mode
+      type:
+        scalar: string
+    - name: ports
+      type:
+        map:
+          elementType:
+            namedType: portV2
+    - name: template
+      type:
+        map:
+          fields:
+          - name: image
+            type:
+              scalar: string
+`)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}()
+
+func newMappingConverter(t testing.TB) *merge.MappingConverter {
+	converter, err := merge.NewMappingConverter(
+		map[fieldpath.APIVersion]typed.ParseableType{
+			"v1": mappingParser.Type("v1"),
+			"v2": mappingParser.Type("v2"),
+		},
+		merge.VersionMapping{
+			From: "v1",
+			To:   "v2",
+			Fields: []merge.FieldMapping{
+				{From: []string{"spec", "replicas"}, To: []string{"spec", "size"}},
+				{From: []string{"spec", "mode"}, To: []string{"spec", "mode"}, Values: map[string]string{"Fast": "fast", "Slow": "slow"}},
+				{From: []string{"spec", "ports", "proto"}, To: []string{"spec", "ports", "protocol"}},
+				{From: []string{"spec", "ports"}, To: []string{"spec", "ports"}, ListKey: "name"},
+				{From: []string{"image"}, To: []string{"spec", "template", "image"}},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return converter
+}
+
+func TestMappingConverter(t *testing.T) {
+	converter := newMappingConverter(t)
+	v1, err := mappingParser.Type("v1").FromYAML(`
+image: nginx
+spec:
+  replicas: 3
+  mode: Fast
+  ports:
+  - name: http
+    number: 80
+    proto: TCP
+  - name: dns
+    number: 53
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := mappingParser.Type("v2").FromYAML(`
+spec:
+  size: 3
+  mode: fast
+  ports:
+    dns:
+      number: 53
+    http:
+      number: 80
+      protocol: TCP
+  template:
+    image: nginx
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	converted, err := converter.Convert(v1, "v2")
+	if err != nil {
+		t.Fatalf("failed to convert to v2: %v", err)
+	}
+	if comparison, err := converted.Compare(v2); err != nil {
+		t.Fatal(err)
+	} else if !comparison.IsSame() {
+		t.Errorf("unexpected conversion to v2:\n%v", comparison)
+	}
+	back, err := converter.Convert(converted, "v1")
+	if err != nil {
+		t.Fatalf("failed to convert back to v1: %v", err)
+	}
+	if comparison, err := back.Compare(v1); err != nil {
+		t.Fatal(err)
+	} else if !comparison.IsSame() {
+		t.Errorf("unexpected conversion back to v1:\n%v", comparison)
+	}
+
+	// Sets of fields are converted the same way as the objects.
+	set1, err := v1.ToFieldSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	set2, err := v2.ToFieldSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := converter.ConvertSet(set1, "v1", "v2"); err != nil {
+		t.Fatalf("failed to convert set to v2: %v", err)
+	} else if !got.Equals(set2) {
+		t.Errorf("expected set:\n%v\ngot:\n%v", set2, got)
+	}
+	if got, err := converter.ConvertSet(set2, "v2", "v1"); err != nil {
+		t.Fatalf("failed to convert set to v1: %v", err)
+	} else if !got.Equals(set1) {
+		t.Errorf("expected set:\n%v\ngot:\n%v", set1, got)
+	}
+
+	if _, err := converter.Convert(v1, "v3"); !converter.IsMissingVersionError(err) {
+		t.Errorf("expected missing version error, got %v", err)
+	}
+}
+
+func TestMappingConverterChained(t *testing.T) {
+	types := map[fieldpath.APIVersion]typed.ParseableType{
+		"v1": mappingParser.Type("v1"),
+		"v2": mappingParser.Type("v2"),
+		"v3": mappingParser.Type("v3"),
+	}
+	v1v2 := merge.VersionMapping{
+		From: "v1",
+		To:   "v2",
+		Fields: []merge.FieldMapping{
+			{From: []string{"spec", "replicas"}, To: []string{"spec", "size"}},
+			{From: []string{"image"}, To: []string{"spec", "template", "image"}},
+		},
+	}
+	v2v3 := merge.VersionMapping{
+		From: "v2",
+		To:   "v3",
+		Fields: []merge.FieldMapping{
+			{From: []string{"spec", "size"}, To: []string{"spec", "count"}},
+		},
+	}
+	converter, err := merge.NewMappingConverter(types, v1v2, v2v3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, err := mappingParser.Type("v1").FromYAML("image: nginx\nspec:\n  replicas: 3\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v3, err := mappingParser.Type("v3").FromYAML("spec:\n  count: 3\n  template:\n    image: nginx\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// v1 is converted to v3 through v2, and back.
+	converted, err := converter.Convert(v1, "v3")
+	if err != nil {
+		t.Fatalf("failed to convert to v3: %v", err)
+	}
+	if comparison, err := converted.Compare(v3); err != nil {
+		t.Fatal(err)
+	} else if !comparison.IsSame() {
+		t.Errorf("unexpected conversion to v3:\n%v", comparison)
+	}
+	back, err := converter.Convert(v3, "v1")
+	if err != nil {
+		t.Fatalf("failed to convert back to v1: %v", err)
+	}
+	if comparison, err := back.Compare(v1); err != nil {
+		t.Fatal(err)
+	} else if !comparison.IsSame() {
+		t.Errorf("unexpected conversion back to v1:\n%v", comparison)
+	}
+	want := _NS(_P("spec", "count"), _P("spec", "template", "image"))
+	if got, err := converter.ConvertSet(_NS(_P("spec", "replicas"), _P("image")), "v1", "v3"); err != nil {
+		t.Fatalf("failed to convert set to v3: %v", err)
+	} else if !got.Equals(want) {
+		t.Errorf("expected set:\n%v\ngot:\n%v", want, got)
+	}
+
+	// Without a mapping from v1, it can't be converted to v3, which
+	// isn't a missing version: the managers at v1 aren't dropped.
+	converter, err = merge.NewMappingConverter(types, v2v3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := converter.Convert(v1, "v3"); err == nil || converter.IsMissingVersionError(err) {
+		t.Errorf("expected an error other than a missing version, got %v", err)
+	}
+	if _, err := converter.ConvertSet(_NS(_P("image")), "v1", "v3"); err == nil || converter.IsMissingVersionError(err) {
+		t.Errorf("expected an error other than a missing version, got %v", err)
+	}
+	updater := &merge.Updater{Converter: converter}
+	managers := fieldpath.ManagedFields{"v1-applier": fieldpath.NewVersionedSet(_NS(_P("image")), "v1", true)}
+	if _, _, err := updater.Update(v3, v3, "v3", managers, "v3-updater"); err == nil {
+		t.Error("expected updating with managers at an unconnected version to fail")
+	}
+}
+
+func TestMappingConverterInvalid(t *testing.T) {
+	types := map[fieldpath.APIVersion]typed.ParseableType{
+		"v1": mappingParser.Type("v1"),
+		"v2": mappingParser.Type("v2"),
+	}
+	tests := map[string][]merge.VersionMapping{
+		"unknown version": {{From: "v1", To: "v3"}},
+		"empty path": {{
+			From:   "v1",
+			To:     "v2",
+			Fields: []merge.FieldMapping{{From: []string{"image"}}},
+		}},
+		"duplicate": {{From: "v1", To: "v2"}, {From: "v1", To: "v2"}},
+	}
+	for name, mappings := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := merge.NewMappingConverter(types, mappings...); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+
+	types["v3"] = mappingParser.Type("v1")
+	if _, err := merge.NewMappingConverter(types); err == nil {
+		t.Fatal("expected an error for versions with the same type")
+	}
+}
+
+func TestMappingConverterMultipleAppliers(t *testing.T) {
+	tests := map[string]TestCase{
+		"conflict_across_versions": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						image: nginx
+						spec:
+						  replicas: 3
+						  ports:
+						  - name: http
+						    number: 80
+					`,
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v2",
+					Object: `
+						spec:
+						  size: 5
+						  ports:
+						    http:
+						      number: 8080
+					`,
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "apply-one", Path: _P("spec", "replicas")},
+						merge.Conflict{Manager: "apply-one", Path: _P("spec", "ports", _KBF("name", "http"), "number")},
+					},
+				},
+				Apply{
+					Manager:    "apply-two",
+					APIVersion: "v2",
+					Object: `
+						spec:
+						  mode: slow
+						  template:
+						    image: nginx
+					`,
+				},
+			},
+			Object: `
+				spec:
+				  size: 3
+				  mode: slow
+				  ports:
+				    http:
+				      number: 80
+				  template:
+				    image: nginx
+			`,
+			APIVersion: "v2",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(
+						_P("image"),
+						_P("spec", "replicas"),
+						_P("spec", "ports", _KBF("name", "http")),
+						_P("spec", "ports", _KBF("name", "http"), "name"),
+						_P("spec", "ports", _KBF("name", "http"), "number"),
+					),
+					"v1",
+					true,
+				),
+				"apply-two": fieldpath.NewVersionedSet(
+					_NS(
+						_P("spec", "mode"),
+						_P("spec", "template", "image"),
+					),
+					"v2",
+					true,
+				),
+			},
+		},
+	}
+
+	converter := newMappingConverter(t)
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.TestWithConverter(mappingParser, converter); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
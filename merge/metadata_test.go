@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+)
+
+func TestManagerMetadata(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := State{
+		Updater: &merge.Updater{
+			Converter: renamingConverter{leafFieldsParser},
+			Now:       func() time.Time { return now },
+		},
+		Parser: leafFieldsParser,
+	}
+	if err := state.Apply("numeric: 1\nstring: a\n", "v1", "apply-one", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	applied := now
+	labels := map[string]string{"team": "platform"}
+	metadata := fieldpath.MetadataOf(state.Managers["apply-one"])
+	if !metadata.Time.Equal(applied) {
+		t.Errorf("expected apply time %v, got %v", applied, metadata.Time)
+	}
+	metadata.Subresource = "scale"
+	metadata.Labels = labels
+	set := state.Managers["apply-one"]
+	state.Managers["apply-one"] = fieldpath.NewVersionedSetWithMetadata(set.Set(), set.APIVersion(), set.Applied(), metadata)
+
+	// The metadata of a manager is kept when another manager takes its
+	// fields, and only the time of the operating manager changes.
+	now = now.Add(time.Hour)
+	if err := state.Update("numeric: 2\nstring: a\n", "v1", "controller"); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if got := fieldpath.MetadataOf(state.Managers["apply-one"]); !sameMetadata(got, metadata) {
+		t.Errorf("expected metadata of apply-one %+v, got %+v", metadata, got)
+	}
+	if got := fieldpath.MetadataOf(state.Managers["controller"]); !got.Time.Equal(now) {
+		t.Errorf("expected update time %v, got %v", now, got.Time)
+	}
+
+	now = now.Add(time.Hour)
+	if err := state.Apply("string: b\nbool: true\n", "v1", "apply-one", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	want := fieldpath.ManagerMetadata{Time: now, Subresource: "scale", Labels: labels}
+	if got := fieldpath.MetadataOf(state.Managers["apply-one"]); !sameMetadata(got, want) {
+		t.Errorf("expected metadata of apply-one %+v, got %+v", want, got)
+	}
+
+	// Operations that don't change the fields of the manager don't
+	// change its time either.
+	now = now.Add(time.Hour)
+	if err := state.Apply("string: b\nbool: true\n", "v1", "apply-one", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	if err := state.Update("numeric: 2\nstring: b\nbool: true\n", "v1", "controller"); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if got := fieldpath.MetadataOf(state.Managers["apply-one"]); !sameMetadata(got, want) {
+		t.Errorf("expected metadata of apply-one %+v after a no-op apply, got %+v", want, got)
+	}
+	if got := fieldpath.MetadataOf(state.Managers["controller"]); !got.Time.Equal(now.Add(-2 * time.Hour)) {
+		t.Errorf("expected update time %v after a no-op update, got %v", now.Add(-2*time.Hour), got.Time)
+	}
+}
+
+// sameMetadata returns true if the two metadata are the same.
+func sameMetadata(lhs, rhs fieldpath.ManagerMetadata) bool {
+	orEmpty := func(s *fieldpath.Set) *fieldpath.Set {
+		if s == nil {
+			return fieldpath.NewSet()
+		}
+		return s
+	}
+	return lhs.Time.Equal(rhs.Time) &&
+		lhs.Subresource == rhs.Subresource &&
+		reflect.DeepEqual(lhs.Labels, rhs.Labels) &&
+		orEmpty(lhs.Contested).Equals(orEmpty(rhs.Contested)) &&
+		orEmpty(lhs.Deleted).Equals(orEmpty(rhs.Deleted))
+}
+
+func TestManagerMetadataOptions(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{Converter: renamingConverter{leafFieldsParser}},
+		Parser:  leafFieldsParser,
+	}
+	before := time.Now()
+	tv, err := leafFieldsParser.Type("v1").FromYAML("numeric: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := map[string]string{"team": "platform"}
+	if err := state.ApplyObjectWithOptions(tv, "v1", "apply-one", false, merge.Options{Labels: labels}); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	metadata := fieldpath.MetadataOf(state.Managers["apply-one"])
+	if metadata.Time.Before(before) {
+		t.Errorf("expected the apply time to default to the current time, got %v", metadata.Time)
+	}
+	if !reflect.DeepEqual(metadata.Labels, labels) {
+		t.Errorf("expected labels %v, got %v", labels, metadata.Labels)
+	}
+
+	// The labels are kept unless new ones are given.
+	tv, err = leafFieldsParser.Type("v1").FromYAML("numeric: 2\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := state.ApplyObject(tv, "v1", "apply-one", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	if got := fieldpath.MetadataOf(state.Managers["apply-one"]).Labels; !reflect.DeepEqual(got, labels) {
+		t.Errorf("expected labels %v to be kept, got %v", labels, got)
+	}
+
+	tv, err = leafFieldsParser.Type("v1").FromYAML("numeric: 2\nstring: a\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := &merge.Scope{Subresource: "scale", Fields: _NS(_P("string"))}
+	if err := state.UpdateObjectWithOptions(tv, "v1", "controller", merge.Options{Scope: scope, Labels: map[string]string{}}); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	want := fieldpath.ManagerMetadata{Subresource: "scale", Labels: map[string]string{}}
+	got := fieldpath.MetadataOf(state.Managers[fieldpath.ScopedManager("controller", "scale")])
+	if got.Time.Before(before) {
+		t.Errorf("expected the update time to default to the current time, got %v", got.Time)
+	}
+	got.Time = time.Time{}
+	if !sameMetadata(got, want) {
+		t.Errorf("expected metadata of controller %+v, got %+v", want, got)
+	}
+}
@@ -1586,6 +1586,39 @@ func (r repeatingConverter) IsMissingVersionError(err error) bool {
 	return err == missingVersionError
 }
 
+// repeatingSetConverter is a repeatingConverter that also converts sets
+// of fields directly.
+type repeatingSetConverter struct {
+	repeatingConverter
+}
+
+var _ merge.SetConverter = repeatingSetConverter{}
+
+// ConvertSet implements merge.SetConverter
+func (r repeatingSetConverter) ConvertSet(set *fieldpath.Set, _, version fieldpath.APIVersion) (*fieldpath.Set, error) {
+	if len(version) < 2 || string(version)[0] != 'v' {
+		return nil, missingVersionError
+	}
+	versionNumber, err := strconv.Atoi(string(version)[1:len(version)])
+	if err != nil {
+		return nil, missingVersionError
+	}
+	out := fieldpath.NewSet()
+	set.Iterate(func(p fieldpath.Path) {
+		converted := make(fieldpath.Path, 0, len(p))
+		for i, pe := range p {
+			// Like repeatingConverter, only nested field names are converted.
+			if i > 0 && pe.FieldName != nil {
+				name := strings.Repeat((*pe.FieldName)[:1], versionNumber)
+				pe = fieldpath.PathElement{FieldName: &name}
+			}
+			converted = append(converted, pe)
+		}
+		out.Insert(converted)
+	})
+	return out, nil
+}
+
 // renamingConverter renames fields by substituting the version suffix of the field name. E.g.
 // converting a map  with a field named "name_v1" from v1 to v2 renames the field to "name_v2".
 // Fields without a version suffix are not converted; they are the same in all versions.
@@ -1715,85 +1748,111 @@ func TestMultipleApplierAtomicMaps(t *testing.T) {
 	}
 }
 
-func BenchmarkMultipleApplierRecursiveRealConversion(b *testing.B) {
-	test := TestCase{
-		Ops: []Operation{
-			Apply{
-				Manager: "apply-one",
-				Object: `
-					mapOfMapsRecursive:
-					  a:
-					    b:
-					  c:
-					    d:
-				`,
-				APIVersion: "v1",
-			},
-			Apply{
-				Manager: "apply-two",
-				Object: `
-					mapOfMapsRecursive:
-					  aa:
-					  cc:
-					    dd:
-				`,
-				APIVersion: "v2",
-			},
-			Update{
-				Manager: "controller",
-				Object: `
-					mapOfMapsRecursive:
-					  aaa:
-					    bbb:
-					      ccc:
-					        ddd:
-					  ccc:
-					    ddd:
-					      eee:
-					        fff:
-					`,
-				APIVersion: "v3",
-			},
-			Apply{
-				Manager: "apply-one",
-				Object: `
-					mapOfMapsRecursive:
+// multipleApplierRecursiveTest applies and updates a recursive type in
+// several versions, which are converted by repeatingConverter.
+var multipleApplierRecursiveTest = TestCase{
+	Ops: []Operation{
+		Apply{
+			Manager: "apply-one",
+			Object: `
+				mapOfMapsRecursive:
+				  a:
+				    b:
+				  c:
+				    d:
+			`,
+			APIVersion: "v1",
+		},
+		Apply{
+			Manager: "apply-two",
+			Object: `
+				mapOfMapsRecursive:
+				  aa:
+				  cc:
+				    dd:
+			`,
+			APIVersion: "v2",
+		},
+		Update{
+			Manager: "controller",
+			Object: `
+				mapOfMapsRecursive:
+				  aaa:
+				    bbb:
+				      ccc:
+				        ddd:
+				  ccc:
+				    ddd:
+				      eee:
+				        fff:
 				`,
-				APIVersion: "v4",
-			},
+			APIVersion: "v3",
 		},
-		Object: `
-			mapOfMapsRecursive:
-			  aaaa:
-			  cccc:
-			    dddd:
-			      eeee:
-			        ffff:
-		`,
-		APIVersion: "v4",
-		Managed: fieldpath.ManagedFields{
-			"apply-two": fieldpath.NewVersionedSet(
-				_NS(
-					_P("mapOfMapsRecursive", "aa"),
-					_P("mapOfMapsRecursive", "cc"),
-					_P("mapOfMapsRecursive", "cc", "dd"),
-				),
-				"v2",
-				false,
+		Apply{
+			Manager: "apply-one",
+			Object: `
+				mapOfMapsRecursive:
+			`,
+			APIVersion: "v4",
+		},
+	},
+	Object: `
+		mapOfMapsRecursive:
+		  aaaa:
+		  cccc:
+		    dddd:
+		      eeee:
+		        ffff:
+	`,
+	APIVersion: "v4",
+	Managed: fieldpath.ManagedFields{
+		"apply-one": fieldpath.NewVersionedSet(
+			_NS(
+				_P("mapOfMapsRecursive"),
 			),
-			"controller": fieldpath.NewVersionedSet(
-				_NS(
-					_P("mapOfMapsRecursive", "ccc", "ddd", "eee"),
-					_P("mapOfMapsRecursive", "ccc", "ddd", "eee", "fff"),
-				),
-				"v3",
-				false,
+			"v4",
+			true,
+		),
+		"apply-two": fieldpath.NewVersionedSet(
+			_NS(
+				_P("mapOfMapsRecursive", "aa"),
+				_P("mapOfMapsRecursive", "cc"),
+				_P("mapOfMapsRecursive", "cc", "dd"),
 			),
-		},
+			"v2",
+			true,
+		),
+		"controller": fieldpath.NewVersionedSet(
+			_NS(
+				_P("mapOfMapsRecursive", "ccc", "ddd", "eee"),
+				_P("mapOfMapsRecursive", "ccc", "ddd", "eee", "fff"),
+			),
+			"v3",
+			false,
+		),
+	},
+}
+
+func TestMultipleAppliersSetConversion(t *testing.T) {
+	converter := repeatingSetConverter{repeatingConverter{nestedTypeParser}}
+	if err := multipleApplierRecursiveTest.TestWithConverter(nestedTypeParser, converter); err != nil {
+		t.Fatal(err)
 	}
+}
+
+func BenchmarkMultipleApplierRecursiveRealConversion(b *testing.B) {
+	benchmarkMultipleApplierRecursive(b, repeatingConverter{nestedTypeParser})
+}
+
+func BenchmarkMultipleApplierRecursiveSetConversion(b *testing.B) {
+	benchmarkMultipleApplierRecursive(b, repeatingSetConverter{repeatingConverter{nestedTypeParser}})
+}
+
+func benchmarkMultipleApplierRecursive(b *testing.B, converter merge.Converter) {
+	test := multipleApplierRecursiveTest
 
 	// Make sure this passes...
-	if err := test.TestWithConverter(nestedTypeParser, repeatingConverter{nestedTypeParser}); err != nil {
+	if err := test.TestWithConverter(nestedTypeParser, converter); err != nil {
 		b.Fatal(err)
 	}
 
@@ -1802,7 +1861,7 @@ func BenchmarkMultipleApplierRecursiveRealConversion(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		if err := test.BenchWithConverter(nestedTypeParser, repeatingConverter{nestedTypeParser}); err != nil {
+		if err := test.BenchWithConverter(nestedTypeParser, converter); err != nil {
 			b.Fatal(err)
 		}
 	}
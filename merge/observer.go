@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// Observer is notified of what Updater operations do to the object and
+// its managers. Each function is optional. Except for Conflicts, they are
+// only called once an Update or an Apply succeeds, and never for dry runs
+// (see Options). Managers are given by name, without the subresource of
+// their scope, while the fields of managers are keyed as in ManagedFields.
+type Observer struct {
+	// Pruned is called when an apply removes fields from the object
+	// because the applier applied them last time, but not anymore, and
+	// no other manager owns them. The fields are given at the version of
+	// lastApplied, the fields that the applier owned before the apply.
+	Pruned func(manager string, pruned *fieldpath.Set, lastApplied fieldpath.VersionedSet)
+	// Conflicts is called when an apply fails because of conflicts.
+	Conflicts func(manager string, conflicts Conflicts)
+	// Taken is called when a manager takes fields owned by other
+	// managers, by updating them, or by forcing an apply that conflicts
+	// with them. The fields are given by previous owner, at its version.
+	Taken func(manager string, taken fieldpath.ManagedFields)
+	// Removed is called when a manager is removed because the version of
+	// its fields doesn't exist anymore.
+	Removed func(manager string, removed fieldpath.VersionedSet)
+}
+
+// observation holds the events of an operation until it succeeds.
+type observation struct {
+	events []func(*Observer)
+	// removed are the managers already known to be removed.
+	removed map[string]bool
+}
+
+// observing returns a copy of the Updater that holds the events of one
+// operation for the Observer, until notify is called. The Observer isn't
+// notified of dry runs.
+func (s *Updater) observing(dryRun bool) *Updater {
+	if s.Observer == nil {
+		return s
+	}
+	observed := *s
+	if dryRun {
+		observed.Observer = nil
+		return &observed
+	}
+	observed.observation = &observation{removed: map[string]bool{}}
+	return &observed
+}
+
+// observe records an event of the current operation.
+func (s *Updater) observe(event func(*Observer)) {
+	if s.observation != nil {
+		s.observation.events = append(s.observation.events, event)
+	}
+}
+
+// observeRemoved records that a manager was removed because its version
+// doesn't exist anymore, unless it already was.
+func (s *Updater) observeRemoved(manager string, removed fieldpath.VersionedSet) {
+	if s.observation == nil || s.observation.removed[manager] {
+		return
+	}
+	s.observation.removed[manager] = true
+	s.observe(func(o *Observer) {
+		if o.Removed != nil {
+			o.Removed(managerName(manager), removed)
+		}
+	})
+}
+
+// observeConflicts notifies the Observer of the conflicts of an apply,
+// which fails because of them.
+func (s *Updater) observeConflicts(manager string, conflicts Conflicts) {
+	if s.Observer != nil && s.Observer.Conflicts != nil {
+		s.Observer.Conflicts(managerName(manager), conflicts)
+	}
+}
+
+// notify notifies the Observer of the events of the operation, which
+// succeeded.
+func (s *Updater) notify() {
+	if s.observation == nil {
+		return
+	}
+	for _, event := range s.observation.events {
+		event(s.Observer)
+	}
+	s.observation.events = nil
+}
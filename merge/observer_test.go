@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// recorder records the notifications of an Observer as strings.
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) observer() *merge.Observer {
+	return &merge.Observer{
+		Pruned: func(manager string, pruned *fieldpath.Set, lastApplied fieldpath.VersionedSet) {
+			r.events = append(r.events, fmt.Sprintf("%v pruned %v, last applied %v", manager, pruned, lastApplied.Set()))
+		},
+		Conflicts: func(manager string, conflicts merge.Conflicts) {
+			r.events = append(r.events, fmt.Sprintf("%v conflicts: %v", manager, conflicts))
+		},
+		Taken: func(manager string, taken fieldpath.ManagedFields) {
+			for previous, set := range taken {
+				r.events = append(r.events, fmt.Sprintf("%v took %v from %v", manager, set.Set(), previous))
+			}
+		},
+		Removed: func(manager string, removed fieldpath.VersionedSet) {
+			r.events = append(r.events, fmt.Sprintf("%v removed at %v", manager, removed.APIVersion()))
+		},
+	}
+}
+
+// expect checks the events recorded since the last call.
+func (r *recorder) expect(t *testing.T, events ...string) {
+	t.Helper()
+	if (len(r.events) != 0 || len(events) != 0) && !reflect.DeepEqual(r.events, events) {
+		t.Errorf("expected events %q, got %q", events, r.events)
+	}
+	r.events = nil
+}
+
+func TestObserver(t *testing.T) {
+	r := &recorder{}
+	state := State{
+		Updater: &merge.Updater{
+			Converter: renamingConverter{leafFieldsParser},
+			Observer:  r.observer(),
+		},
+		Parser: leafFieldsParser,
+	}
+
+	if err := state.Apply("numeric: 1\nstring: a\n", "v1", "apply-one", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	r.expect(t)
+
+	// The conflicts are reported even though the apply fails, but
+	// nothing else is.
+	if err := state.Apply("numeric: 2\n", "v1", "apply-two", false); err == nil {
+		t.Fatal("expected conflicts")
+	}
+	r.expect(t, fmt.Sprintf("apply-two conflicts: %v", merge.Conflicts{{Manager: "apply-one", Path: _P("numeric")}}))
+
+	// Dry runs don't notify the Observer, even of conflicts.
+	plan := &merge.ApplyPlan{}
+	if _, _, err := state.Updater.ApplyWithOptions(state.Live, mustParse(t, "numeric: 2\n"), "v1", state.Managers.Copy(), "apply-two", false, merge.Options{Plan: plan, DryRun: true}); err == nil {
+		t.Fatal("expected conflicts")
+	}
+	if len(plan.Conflicts) != 1 {
+		t.Errorf("expected the plan to have the conflict, got %v", plan.Conflicts)
+	}
+	r.expect(t)
+
+	// Neither do plans.
+	if _, err := state.Updater.PlanApply(state.Live, mustParse(t, "numeric: 2\n"), "v1", state.Managers, "apply-two"); err != nil {
+		t.Fatalf("failed to plan: %v", err)
+	}
+	r.expect(t)
+
+	if err := state.Apply("numeric: 2\n", "v1", "apply-two", true); err != nil {
+		t.Fatalf("failed to force apply: %v", err)
+	}
+	r.expect(t, fmt.Sprintf("apply-two took %v from apply-one", _NS(_P("numeric"))))
+
+	if err := state.Update("numeric: 2\nstring: b\n", "v1", "controller"); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	r.expect(t, fmt.Sprintf("controller took %v from apply-one", _NS(_P("string"))))
+
+	if err := state.Apply("numeric: 2\nbool: true\n", "v1", "apply-two", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	r.expect(t)
+
+	// The pruned fields are those that the applier stops applying and
+	// that no other manager owns.
+	if err := state.Apply("numeric: 2\n", "v1", "apply-two", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	r.expect(t, fmt.Sprintf("apply-two pruned %v, last applied %v", _NS(_P("bool")), _NS(_P("numeric"), _P("bool"))))
+}
+
+func mustParse(t *testing.T, object typed.YAMLObject) *typed.TypedValue {
+	t.Helper()
+	tv, err := leafFieldsParser.Type("v1").FromYAML(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tv
+}
+
+func TestObserverRemovedVersion(t *testing.T) {
+	r := &recorder{}
+	converter := &specificVersionConverter{
+		AcceptedVersions: []fieldpath.APIVersion{"v1", "v2"},
+	}
+	state := State{
+		Updater: &merge.Updater{Converter: converter, Observer: r.observer()},
+		Parser:  DeducedParser,
+	}
+
+	if err := state.Update(`{"v1": 0}`, "v1", "v1"); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if err := state.Update(`{"v1": 0, "v2": 0}`, "v2", "v2"); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	r.expect(t)
+
+	// Remove v1, add v3 instead.
+	converter.AcceptedVersions = []fieldpath.APIVersion{"v2", "v3"}
+	if err := state.Update(`{"v1": 0, "v2": 0, "v3": 0}`, "v3", "v3"); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	r.expect(t, "v1 removed at v1")
+}
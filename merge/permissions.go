@@ -0,0 +1,104 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// Permission restricts the fields that some managers may own. Deleting a
+// field in an apply requires owning it and everything under it.
+type Permission struct {
+	// Managers is a pattern, with the syntax of path.Match, matching the
+	// names of the managers that the permission applies to.
+	Managers string
+	// Allowed, if not nil, are the only fields that the managers may
+	// own, along with everything under them.
+	Allowed *fieldpath.Set
+	// Forbidden are fields that the managers may not own, along with
+	// everything under them, even if they are allowed.
+	Forbidden *fieldpath.Set
+}
+
+// Permissions restrict the fields that managers may own. The first
+// permission matching a manager applies, and managers that match none
+// may own any field. Managers are matched by name, in every scope. The
+// paths are the same in every version.
+type Permissions []Permission
+
+// violations returns the fields of set that manager may not own.
+func (p Permissions) violations(manager string, set *fieldpath.Set) (*fieldpath.Set, error) {
+	violations := fieldpath.NewSet()
+	for _, permission := range p {
+		matched, err := path.Match(permission.Managers, manager)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manager pattern %q: %v", permission.Managers, err)
+		}
+		if !matched {
+			continue
+		}
+		set.Iterate(func(p fieldpath.Path) {
+			if permission.Allowed != nil && !hasPrefix(permission.Allowed, p) ||
+				permission.Forbidden != nil && hasPrefix(permission.Forbidden, p) {
+				violations.Insert(p.Copy())
+			}
+		})
+		break
+	}
+	return violations, nil
+}
+
+// checkPermissions returns a PermissionError if the manager under key
+// may not own some fields of set, given at version.
+func (s *Updater) checkPermissions(key string, version fieldpath.APIVersion, set *fieldpath.Set) error {
+	if len(s.Permissions) == 0 {
+		return nil
+	}
+	manager := managerName(key)
+	violations, err := s.Permissions.violations(manager, set)
+	if err != nil {
+		return err
+	}
+	if !violations.Empty() {
+		return PermissionError{Manager: manager, APIVersion: version, Paths: violations}
+	}
+	return nil
+}
+
+// PermissionError is returned when an operation would give a manager
+// fields that its Permissions don't let it own, or would delete them.
+type PermissionError struct {
+	Manager    string
+	APIVersion fieldpath.APIVersion
+	// Paths are the fields that the manager may not own, at APIVersion.
+	Paths *fieldpath.Set
+}
+
+var _ error = PermissionError{}
+
+// Error implements error.
+func (e PermissionError) Error() string {
+	messages := []string{fmt.Sprintf("manager %q may not own:", e.Manager)}
+	e.Paths.Iterate(func(p fieldpath.Path) {
+		messages = append(messages, fmt.Sprintf("- %v", p))
+	})
+	return strings.Join(messages, "\n")
+}
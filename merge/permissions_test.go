@@ -0,0 +1,179 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+func TestPermissions(t *testing.T) {
+	permissions := merge.Permissions{
+		{Managers: "autoscaler", Allowed: _NS(_P("numeric"))},
+		{Managers: "app-*", Forbidden: _NS(_P("bool"))},
+	}
+	tests := []struct {
+		name       string
+		apply      bool
+		manager    string
+		object     string
+		violations *fieldpath.Set
+	}{
+		{
+			name:    "allowed update",
+			manager: "autoscaler",
+			object:  "numeric: 2\nstring: a\n",
+		},
+		{
+			name:       "update outside of allowed",
+			manager:    "autoscaler",
+			object:     "numeric: 2\nstring: b\nbool: true\n",
+			violations: _NS(_P("string"), _P("bool")),
+		},
+		{
+			name:       "forbidden apply",
+			apply:      true,
+			manager:    "app-web",
+			object:     "string: a\nbool: true\n",
+			violations: _NS(_P("bool")),
+		},
+		{
+			name:    "allowed apply",
+			apply:   true,
+			manager: "app-web",
+			object:  "string: a\n",
+		},
+		{
+			name:    "no permission",
+			apply:   true,
+			manager: "admin",
+			object:  "bool: true\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := State{
+				Updater: &merge.Updater{Converter: renamingConverter{leafFieldsParser}, Permissions: permissions},
+				Parser:  leafFieldsParser,
+			}
+			if err := state.Update("numeric: 1\nstring: a\n", "v1", "controller"); err != nil {
+				t.Fatalf("failed to update: %v", err)
+			}
+			var err error
+			if test.apply {
+				err = state.Apply(typed.YAMLObject(test.object), "v1", test.manager, true)
+			} else {
+				err = state.Update(typed.YAMLObject(test.object), "v1", test.manager)
+			}
+			if test.violations == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			permissionErr, ok := err.(merge.PermissionError)
+			if !ok {
+				t.Fatalf("expected a permission error, got: %v", err)
+			}
+			if permissionErr.Manager != test.manager || permissionErr.APIVersion != "v1" || !permissionErr.Paths.Equals(test.violations) {
+				t.Errorf("expected violations of %q:\n%v\ngot:\n%v", test.manager, test.violations, permissionErr)
+			}
+		})
+	}
+}
+
+func TestPermissionsInvalidPattern(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{
+			Converter:   renamingConverter{leafFieldsParser},
+			Permissions: merge.Permissions{{Managers: "[", Forbidden: _NS(_P("bool"))}},
+		},
+		Parser: leafFieldsParser,
+	}
+	err := state.Update("bool: true\n", "v1", "controller")
+	if _, ok := err.(merge.PermissionError); err == nil || ok {
+		t.Fatalf("expected an invalid pattern error, got: %v", err)
+	}
+}
+
+func TestPermissionsDeletions(t *testing.T) {
+	permissions := merge.Permissions{
+		{Managers: "pruner", Forbidden: _NS(_P("list", _KBF("name", "a"), "value"))},
+		{Managers: "cleaner", Allowed: _NS(_P("list", _KBF("name", "b")))},
+	}
+	tests := []struct {
+		name       string
+		manager    string
+		deletions  *fieldpath.Set
+		violations *fieldpath.Set
+	}{
+		{
+			name:       "forbidden field under deletion",
+			manager:    "pruner",
+			deletions:  _NS(_P("list", _KBF("name", "a"))),
+			violations: _NS(_P("list", _KBF("name", "a"), "value")),
+		},
+		{
+			name:      "allowed deletion",
+			manager:   "pruner",
+			deletions: _NS(_P("list", _KBF("name", "b"))),
+		},
+		{
+			name:      "deletion outside of allowed",
+			manager:   "cleaner",
+			deletions: _NS(_P("list", _KBF("name", "a"))),
+			violations: _NS(
+				_P("list", _KBF("name", "a")),
+				_P("list", _KBF("name", "a"), "name"),
+				_P("list", _KBF("name", "a"), "value"),
+			),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := State{
+				Updater: &merge.Updater{Converter: renamingConverter{associativeListParser}, Permissions: permissions},
+				Parser:  associativeListParser,
+			}
+			if err := state.Update("list:\n- name: a\n  value: 1\n- name: b\n  value: 2\n", "v1", "controller"); err != nil {
+				t.Fatalf("failed to update: %v", err)
+			}
+			tv, err := associativeListParser.Type("v1").FromYAML("{}")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = state.ApplyObjectWithOptions(tv, "v1", test.manager, true, merge.Options{Deletions: test.deletions})
+			if test.violations == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			permissionErr, ok := err.(merge.PermissionError)
+			if !ok {
+				t.Fatalf("expected a permission error, got: %v", err)
+			}
+			if !permissionErr.Paths.Equals(test.violations) {
+				t.Errorf("expected violations of %q:\n%v\ngot:\n%v", test.manager, test.violations, permissionErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// ApplyPlan describes what an Apply would do, as returned by PlanApply,
+// or filled by ApplyWithOptions when it is given one in its Options.
+type ApplyPlan struct {
+	// Object is the object after the apply.
+	Object *typed.TypedValue
+	// Changed is false if the apply doesn't change the object, in which
+	// case Apply returns a nil object.
+	Changed bool
+	// Managers are the managed fields after the apply.
+	Managers fieldpath.ManagedFields
+
+	// Pruned are the fields removed from the object because the applier
+	// applied them last time, but not anymore, and no other manager owns
+	// them. They are given at the version of that last apply.
+	Pruned *fieldpath.Set
+	// PrunedVersion is the version of Pruned.
+	PrunedVersion fieldpath.APIVersion
+
+	// Conflicts are the conflicts that Apply returns without force,
+	// sorted by manager and path. Object and Managers are the result of
+	// a forced apply.
+	Conflicts Conflicts
+	// Moved are the fields that other managers stop owning, by manager
+	// and at the version of each manager. Conflicting fields are taken
+	// over by the applier, while fields removed from the object are no
+	// longer owned by anyone.
+	Moved fieldpath.ManagedFields
+
+	// previous are the managers before the apply.
+	previous fieldpath.ManagedFields
+}
+
+// PlanApply returns what Apply would do, without doing it: neither
+// liveObject nor managers are changed, and the Observer isn't notified.
+// The plan is computed as if the apply was forced, and lists the
+// conflicts that make an apply that isn't forced fail.
+func (s *Updater) PlanApply(liveObject, configObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string) (*ApplyPlan, error) {
+	plan := &ApplyPlan{}
+	_, _, err := s.ApplyWithOptions(liveObject, configObject, version, managers.Copy(), manager, false, Options{Plan: plan, DryRun: true})
+	if _, ok := err.(Conflicts); err != nil && !ok {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// prunedFields returns the fields pruned from merged, at the version of
+// the last apply, which are empty if that version doesn't exist anymore.
+func (s *Updater) prunedFields(merged, pruned *typed.TypedValue, lastSet fieldpath.VersionedSet) (*fieldpath.Set, error) {
+	if lastSet == nil || lastSet.Set().Empty() {
+		return fieldpath.NewSet(), nil
+	}
+	convertedMerged, err := s.Converter.Convert(merged, lastSet.APIVersion())
+	if err != nil {
+		if s.Converter.IsMissingVersionError(err) {
+			// Nothing is pruned.
+			return fieldpath.NewSet(), nil
+		}
+		return nil, fmt.Errorf("failed to convert merged object to last applied version: %v", err)
+	}
+	convertedPruned, err := s.Converter.Convert(pruned, lastSet.APIVersion())
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert pruned object to last applied version: %v", err)
+	}
+	compare, err := convertedMerged.Compare(convertedPruned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare pruned object: %v", err)
+	}
+	return compare.Removed, nil
+}
+
+// setMoved sets the fields that managers other than the applier have
+// lost, and sorts the conflicts.
+func (p *ApplyPlan) setMoved(applier string) {
+	p.Moved = fieldpath.ManagedFields{}
+	for manager, before := range p.previous {
+		if manager == applier {
+			continue
+		}
+		after := fieldpath.NewSet()
+		if managerSet, ok := p.Managers[manager]; ok {
+			if managerSet.APIVersion() != before.APIVersion() {
+				continue
+			}
+			after = managerSet.Set()
+		}
+		lost := before.Set().Difference(after)
+		if !lost.Empty() {
+			p.Moved[manager] = fieldpath.NewVersionedSet(lost, before.APIVersion(), before.Applied())
+		}
+	}
+	sort.Slice(p.Conflicts, func(i, j int) bool {
+		if p.Conflicts[i].Manager != p.Conflicts[j].Manager {
+			return p.Conflicts[i].Manager < p.Conflicts[j].Manager
+		}
+		return p.Conflicts[i].Path.Compare(p.Conflicts[j].Path) < 0
+	})
+	p.previous = nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+)
+
+func TestPlanApply(t *testing.T) {
+	tests := map[string]TestCase{
+		"no_change": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+					`,
+				},
+				PlanApply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+					`,
+					Conflicts: merge.Conflicts{},
+					Pruned:    _NS(),
+					Moved:     fieldpath.ManagedFields{},
+				},
+			},
+		},
+		"prune": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "a"
+					`,
+				},
+				PlanApply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+					`,
+					Conflicts: merge.Conflicts{},
+					Pruned:    _NS(_P("string")),
+					Moved:     fieldpath.ManagedFields{},
+				},
+			},
+			Object: `
+				numeric: 1
+				string: "a"
+			`,
+			APIVersion: "v1",
+		},
+		"conflicts": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "a"
+					`,
+				},
+				Update{
+					Manager:    "controller",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "b"
+						bool: true
+					`,
+				},
+				PlanApply{
+					Manager:    "apply-two",
+					APIVersion: "v1",
+					Object: `
+						numeric: 2
+						string: "c"
+					`,
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "apply-one", Path: _P("numeric")},
+						merge.Conflict{Manager: "controller", Path: _P("string")},
+					},
+					Pruned: _NS(),
+					Moved: fieldpath.ManagedFields{
+						"apply-one":  fieldpath.NewVersionedSet(_NS(_P("numeric")), "v1", true),
+						"controller": fieldpath.NewVersionedSet(_NS(_P("string")), "v1", false),
+					},
+				},
+			},
+			Object: `
+				numeric: 1
+				string: "b"
+				bool: true
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(_P("numeric")),
+					"v1",
+					true,
+				),
+				"controller": fieldpath.NewVersionedSet(
+					_NS(_P("string"), _P("bool")),
+					"v1",
+					false,
+				),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.Test(leafFieldsParser); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
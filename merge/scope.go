@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// Scope restricts operations to some fields of the object, such as the
+// fields of a subresource. The fields that a manager changes in a scope
+// are owned separately from the fields it changes in other scopes, under
+// the key given by fieldpath.ScopedManager, so that applying in a scope
+// neither prunes nor conflicts with fields of other scopes.
+type Scope struct {
+	// Subresource is the name of the scope, which is recorded in the
+	// metadata of the managers (see fieldpath.MetadataOf).
+	Subresource string
+	// Fields, if not nil, are the only fields that operations in the
+	// scope may change, along with everything under them. If nil, the
+	// scope doesn't restrict the fields, and only sets the subresource.
+	Fields *fieldpath.Set
+}
+
+// managerName returns the name of the manager whose fields are under key
+// in ManagedFields, without its subresource.
+func managerName(key string) string {
+	name, _ := fieldpath.ParseScopedManager(key)
+	return name
+}
+
+// outside returns the fields of set that aren't in the scope.
+func (scope Scope) outside(set *fieldpath.Set) *fieldpath.Set {
+	outside := fieldpath.NewSet()
+	if scope.Fields == nil {
+		return outside
+	}
+	set.Iterate(func(p fieldpath.Path) {
+		if !hasPrefix(scope.Fields, p) {
+			outside.Insert(p.Copy())
+		}
+	})
+	return outside
+}
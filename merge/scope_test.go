@@ -0,0 +1,368 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+var subresourceParser = func() Parser {
+	parser, err := typed.NewParser(`types:
+- name: v1
+  map:
+    fields:
+      - name: spec
+        type:
+          namedType: spec
+      - name: status
+        type:
+          namedType: status
+- name: spec
+  map:
+    fields:
+      - name: replicas
+        type:
+          scalar: numeric
+      - name: image
+        type:
+          scalar: string
+- name: status
+  map:
+    fields:
+      - name: replicas
+        type:
+          scalar: numeric
+      - name: ready
+        type:
+          scalar: numeric
+`)
+	if err != nil {
+		panic(err)
+	}
+	return SameVersionParser{T: parser.Type("v1")}
+}()
+
+var (
+	statusScope = &merge.Scope{Subresource: "status", Fields: _NS(_P("status"))}
+	scaleScope  = &merge.Scope{Subresource: "scale", Fields: _NS(_P("spec", "replicas"))}
+)
+
+func TestScopedAppliers(t *testing.T) {
+	tests := map[string]TestCase{
+		"status_apply_does_not_prune_spec": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "kubectl",
+					APIVersion: "v1",
+					Object: `
+						spec:
+						  replicas: 3
+						  image: nginx
+					`,
+				},
+				Apply{
+					Manager:    "kubectl",
+					APIVersion: "v1",
+					Scope:      statusScope,
+					Object: `
+						status:
+						  replicas: 3
+					`,
+				},
+				Apply{
+					Manager:    "kubectl",
+					APIVersion: "v1",
+					Scope:      statusScope,
+					Object: `
+						status:
+						  ready: 3
+					`,
+				},
+			},
+			Object: `
+				spec:
+				  replicas: 3
+				  image: nginx
+				status:
+				  ready: 3
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"kubectl": fieldpath.NewVersionedSet(
+					_NS(_P("spec", "replicas"), _P("spec", "image")),
+					"v1",
+					true,
+				),
+				fieldpath.ScopedManager("kubectl", "status"): fieldpath.NewVersionedSet(
+					_NS(_P("status", "ready")),
+					"v1",
+					true,
+				),
+			},
+		},
+		"spec_apply_does_not_prune_status": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "kubectl",
+					APIVersion: "v1",
+					Scope:      statusScope,
+					Object: `
+						status:
+						  replicas: 3
+					`,
+				},
+				Apply{
+					Manager:    "kubectl",
+					APIVersion: "v1",
+					Object: `
+						spec:
+						  image: nginx
+					`,
+				},
+			},
+			Object: `
+				spec:
+				  image: nginx
+				status:
+				  replicas: 3
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"kubectl": fieldpath.NewVersionedSet(
+					_NS(_P("spec", "image")),
+					"v1",
+					true,
+				),
+				fieldpath.ScopedManager("kubectl", "status"): fieldpath.NewVersionedSet(
+					_NS(_P("status", "replicas")),
+					"v1",
+					true,
+				),
+			},
+		},
+		"scale_update_conflicts_with_apply": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "kubectl",
+					APIVersion: "v1",
+					Object: `
+						spec:
+						  replicas: 3
+						  image: nginx
+					`,
+				},
+				Update{
+					Manager:    "autoscaler",
+					APIVersion: "v1",
+					Scope:      scaleScope,
+					Object: `
+						spec:
+						  replicas: 5
+						  image: nginx
+					`,
+				},
+				Apply{
+					Manager:    "kubectl",
+					APIVersion: "v1",
+					Object: `
+						spec:
+						  replicas: 3
+						  image: nginx
+					`,
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: fieldpath.ScopedManager("autoscaler", "scale"), Path: _P("spec", "replicas")},
+					},
+				},
+			},
+			Object: `
+				spec:
+				  replicas: 5
+				  image: nginx
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"kubectl": fieldpath.NewVersionedSet(
+					_NS(_P("spec", "image")),
+					"v1",
+					true,
+				),
+				fieldpath.ScopedManager("autoscaler", "scale"): fieldpath.NewVersionedSet(
+					_NS(_P("spec", "replicas")),
+					"v1",
+					false,
+				),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.Test(subresourceParser); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestScopedOperationsOutsideOfScope(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{Converter: renamingConverter{subresourceParser}},
+		Parser:  subresourceParser,
+	}
+	if err := state.Apply("spec:\n  replicas: 3\n", "v1", "kubectl", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+
+	tv, err := subresourceParser.Type("v1").FromYAML("spec:\n  replicas: 5\nstatus:\n  ready: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := state.UpdateObjectWithOptions(tv, "v1", "controller", merge.Options{Scope: statusScope}); err == nil {
+		t.Error("expected an error when updating spec in the status scope")
+	}
+	if err := state.ApplyObjectWithOptions(tv, "v1", "controller", true, merge.Options{Scope: statusScope}); err == nil {
+		t.Error("expected an error when applying spec in the status scope")
+	}
+
+	tv, err = subresourceParser.Type("v1").FromYAML("spec:\n  replicas: 3\nstatus:\n  ready: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := state.UpdateObjectWithOptions(tv, "v1", "controller", merge.Options{Scope: statusScope}); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	set, ok := state.Managers[fieldpath.ScopedManager("controller", "status")]
+	if !ok {
+		t.Fatalf("expected the status to be owned by the scoped controller, got:\n%v", state.Managers)
+	}
+	if subresource := fieldpath.MetadataOf(set).Subresource; subresource != "status" {
+		t.Errorf("expected subresource %q, got %q", "status", subresource)
+	}
+}
+
+func TestScopeWithoutFields(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{Converter: renamingConverter{subresourceParser}},
+		Parser:  subresourceParser,
+	}
+	tv, err := subresourceParser.Type("v1").FromYAML("spec:\n  replicas: 5\nstatus:\n  ready: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A scope without fields doesn't restrict what can be changed.
+	scope := &merge.Scope{Subresource: "status"}
+	if err := state.UpdateObjectWithOptions(tv, "v1", "controller", merge.Options{Scope: scope}); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	want := _NS(_P("spec"), _P("spec", "replicas"), _P("status"), _P("status", "ready"))
+	if got, err := state.Managers.OwnedBy(fieldpath.ScopedManager("controller", "status"), "v1"); err != nil {
+		t.Errorf("failed to get the fields of the scoped controller: %v", err)
+	} else if !got.Equals(want) {
+		t.Errorf("expected the scoped controller to own:\n%v\ngot:\n%v", want, got)
+	}
+}
+
+func TestScopeCheckedAfterUnionNormalization(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{Converter: renamingConverter{unionFieldsParser}},
+		Parser:  unionFieldsParser,
+	}
+	if err := state.Update("type: String\nstring: a\n", "v1", "controller"); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	// Changing the discriminator clears string, which is outside of the
+	// scope.
+	tv, err := unionFieldsParser.Type("v1").FromYAML("type: Numeric\nnumeric: 1\nstring: a\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := &merge.Scope{Subresource: "numeric", Fields: _NS(_P("type"), _P("numeric"))}
+	if err := state.UpdateObjectWithOptions(tv, "v1", "controller", merge.Options{Scope: scope}); err == nil {
+		t.Errorf("expected an error when normalizing the union changes fields outside of the scope, got:\n%v", state.Managers)
+	}
+}
+
+func TestScopedPermissions(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{
+			Converter:   renamingConverter{subresourceParser},
+			Permissions: merge.Permissions{{Managers: "kubelet", Allowed: _NS(_P("spec"))}},
+		},
+		Parser: subresourceParser,
+	}
+	tv, err := subresourceParser.Type("v1").FromYAML("status:\n  ready: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Permissions apply to the manager in every scope.
+	err = state.UpdateObjectWithOptions(tv, "v1", "kubelet", merge.Options{Scope: statusScope})
+	if perr, ok := err.(merge.PermissionError); !ok || perr.Manager != "kubelet" {
+		t.Errorf("expected a PermissionError for %q when updating in a scope, got: %v", "kubelet", err)
+	}
+	err = state.ApplyObjectWithOptions(tv, "v1", "kubelet", false, merge.Options{Scope: statusScope})
+	if perr, ok := err.(merge.PermissionError); !ok || perr.Manager != "kubelet" {
+		t.Errorf("expected a PermissionError for %q when applying in a scope, got: %v", "kubelet", err)
+	}
+}
+
+func TestScopedSharingAndObserver(t *testing.T) {
+	var conflicted, took []string
+	state := State{
+		Updater: &merge.Updater{
+			Converter: renamingConverter{subresourceParser},
+			Sharing:   merge.SharedFields{"kubelet": nil},
+			Observer: &merge.Observer{
+				Conflicts: func(manager string, _ merge.Conflicts) { conflicted = append(conflicted, manager) },
+				Taken:     func(manager string, _ fieldpath.ManagedFields) { took = append(took, manager) },
+			},
+		},
+		Parser: subresourceParser,
+	}
+	tv, err := subresourceParser.Type("v1").FromYAML("status:\n  ready: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := state.ApplyObjectWithOptions(tv, "v1", "kubelet", false, merge.Options{Scope: statusScope}); err != nil {
+		t.Fatalf("failed to apply status: %v", err)
+	}
+	if err := state.Apply("status:\n  ready: 2\n", "v1", "controller", false); err != nil {
+		t.Fatalf("expected the status shared by the scoped kubelet not to conflict: %v", err)
+	}
+	kubelet := state.Managers[fieldpath.ScopedManager("kubelet", "status")]
+	if kubelet == nil || !fieldpath.MetadataOf(kubelet).Contested.Has(_P("status", "ready")) {
+		t.Errorf("expected the scoped kubelet to share the status, got:\n%v", state.Managers)
+	}
+
+	tv, err = subresourceParser.Type("v1").FromYAML("status:\n  ready: 3\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := state.ApplyObjectWithOptions(tv, "v1", "kubelet", false, merge.Options{Scope: statusScope}); err == nil {
+		t.Fatal("expected the scoped kubelet to conflict with the controller")
+	}
+	if err := state.UpdateObjectWithOptions(tv, "v1", "kubelet", merge.Options{Scope: statusScope}); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	if len(conflicted) != 1 || conflicted[0] != "kubelet" || len(took) != 1 || took[0] != "kubelet" {
+		t.Errorf("expected the Observer to be notified of %q, got conflicts of %v and fields taken by %v", "kubelet", conflicted, took)
+	}
+}
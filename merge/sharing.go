@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// SharingPolicy decides which fields are shared between managers. When
+// another manager changes a field that a manager shares, it isn't a
+// conflict: the manager keeps owning the field, which becomes contested
+// until the manager applies it again (see fieldpath.ManagerMetadata).
+type SharingPolicy interface {
+	// Shares returns true if manager shares the field at path, given
+	// at the version of the manager's fields. The manager is given by
+	// name, without the subresource of its scope.
+	Shares(manager string, version fieldpath.APIVersion, path fieldpath.Path) bool
+}
+
+// SharedFields is a SharingPolicy listing, by manager, the fields that
+// the manager shares, along with everything under them. A nil set shares
+// every field of the manager. The paths are the same in every version.
+type SharedFields map[string]*fieldpath.Set
+
+var _ SharingPolicy = SharedFields{}
+
+// Shares implements SharingPolicy.
+func (sf SharedFields) Shares(manager string, _ fieldpath.APIVersion, path fieldpath.Path) bool {
+	set, ok := sf[manager]
+	if !ok {
+		return false
+	}
+	return set == nil || hasPrefix(set, path)
+}
+
+// sharedFields returns the fields of conflictSet that the manager under
+// key shares.
+func (s *Updater) sharedFields(key string, conflictSet fieldpath.VersionedSet) *fieldpath.Set {
+	shared := fieldpath.NewSet()
+	if s.Sharing == nil {
+		return shared
+	}
+	manager := managerName(key)
+	conflictSet.Set().Iterate(func(p fieldpath.Path) {
+		if s.Sharing.Shares(manager, conflictSet.APIVersion(), p) {
+			shared.Insert(p.Copy())
+		}
+	})
+	return shared
+}
+
+// withSet returns v with set as its fields, keeping its version, whether
+// it was applied, its metadata, its deleted fields, and its contested
+// fields that are still in set.
+func withSet(v fieldpath.VersionedSet, set *fieldpath.Set) fieldpath.VersionedSet {
+	return fieldpath.NewVersionedSetWithMetadata(set, v.APIVersion(), v.Applied(), fieldpath.MetadataOf(v))
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+)
+
+func TestSharedFields(t *testing.T) {
+	tests := map[string]TestCase{
+		"shared_field_contested": {
+			Sharing: merge.SharedFields{"platform": _NS(_P("numeric"))},
+			Ops: []Operation{
+				Apply{
+					Manager:    "platform",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "a"
+					`,
+				},
+				Apply{
+					Manager:    "app",
+					APIVersion: "v1",
+					Object: `
+						numeric: 2
+					`,
+				},
+			},
+			Object: `
+				numeric: 2
+				string: "a"
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"platform": fieldpath.NewVersionedSetWithMetadata(
+					_NS(_P("numeric"), _P("string")),
+					"v1",
+					true,
+					fieldpath.ManagerMetadata{Contested: _NS(_P("numeric"))},
+				),
+				"app": fieldpath.NewVersionedSet(
+					_NS(_P("numeric")),
+					"v1",
+					true,
+				),
+			},
+		},
+		"unshared_field_conflicts": {
+			Sharing: merge.SharedFields{"platform": _NS(_P("numeric"))},
+			Ops: []Operation{
+				Apply{
+					Manager:    "platform",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "a"
+					`,
+				},
+				Apply{
+					Manager:    "app",
+					APIVersion: "v1",
+					Object: `
+						numeric: 2
+						string: "b"
+					`,
+					Conflicts: merge.Conflicts{
+						merge.Conflict{Manager: "platform", Path: _P("string")},
+					},
+				},
+			},
+			Object: `
+				numeric: 1
+				string: "a"
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"platform": fieldpath.NewVersionedSet(
+					_NS(_P("numeric"), _P("string")),
+					"v1",
+					true,
+				),
+			},
+		},
+		"reapply_clears_contested": {
+			Sharing: merge.SharedFields{"platform": nil, "app": nil},
+			Ops: []Operation{
+				Apply{
+					Manager:    "platform",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+					`,
+				},
+				Apply{
+					Manager:    "app",
+					APIVersion: "v1",
+					Object: `
+						numeric: 2
+					`,
+				},
+				Apply{
+					Manager:    "platform",
+					APIVersion: "v1",
+					Object: `
+						numeric: 3
+					`,
+				},
+			},
+			Object: `
+				numeric: 3
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"platform": fieldpath.NewVersionedSet(
+					_NS(_P("numeric")),
+					"v1",
+					true,
+				),
+				"app": fieldpath.NewVersionedSetWithMetadata(
+					_NS(_P("numeric")),
+					"v1",
+					true,
+					fieldpath.ManagerMetadata{Contested: _NS(_P("numeric"))},
+				),
+			},
+		},
+		"update_clears_contested": {
+			Sharing: merge.SharedFields{"controller": nil},
+			Ops: []Operation{
+				Update{
+					Manager:    "controller",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "a"
+					`,
+				},
+				Update{
+					Manager:    "other",
+					APIVersion: "v1",
+					Object: `
+						numeric: 2
+						string: "b"
+					`,
+				},
+				Update{
+					Manager:    "controller",
+					APIVersion: "v1",
+					Object: `
+						numeric: 3
+						string: "b"
+					`,
+				},
+			},
+			Object: `
+				numeric: 3
+				string: "b"
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"controller": fieldpath.NewVersionedSetWithMetadata(
+					_NS(_P("numeric"), _P("string")),
+					"v1",
+					false,
+					fieldpath.ManagerMetadata{Contested: _NS(_P("string"))},
+				),
+				"other": fieldpath.NewVersionedSet(
+					_NS(_P("string")),
+					"v1",
+					false,
+				),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.Test(leafFieldsParser); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// TransferOwnership moves the ownership of the fields in set, given at the
+// version of from, along with everything under them, from one manager to
+// another. The object isn't changed. The fields are converted to the
+// version of to, using liveObject if the Converter isn't a SetConverter.
+// to keeps whether it applied its fields. If it doesn't manage anything
+// yet, it gets the version of from, and its fields aren't applied ones
+// until it applies the object itself. The given managers aren't modified.
+func (s *Updater) TransferOwnership(liveObject *typed.TypedValue, managers fieldpath.ManagedFields, from, to string, set *fieldpath.Set) (fieldpath.ManagedFields, error) {
+	s = s.withConversionCache()
+	managers = managers.Copy()
+	fromSet, ok := managers[from]
+	if !ok || from == to {
+		return managers, nil
+	}
+	moved := releaseFields(managers, from, set)
+	if moved.Empty() {
+		return managers, nil
+	}
+
+	toSet, ok := managers[to]
+	if !ok {
+		toSet = fieldpath.NewVersionedSet(fieldpath.NewSet(), fromSet.APIVersion(), false)
+	}
+	moved, err := s.convertOwnedFields(liveObject, moved, fromSet.APIVersion(), toSet.APIVersion())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPermissions(to, toSet.APIVersion(), moved); err != nil {
+		return nil, err
+	}
+	managers[to] = withSet(toSet, toSet.Set().Union(moved))
+	return managers, nil
+}
+
+// ReleaseOwnership removes the fields in set, given at the version of
+// manager, along with everything under them, from the fields owned by
+// manager. The fields stay in the object, owned by the other managers
+// that own them, if any. The given managers aren't modified.
+func (s *Updater) ReleaseOwnership(managers fieldpath.ManagedFields, manager string, set *fieldpath.Set) fieldpath.ManagedFields {
+	managers = managers.Copy()
+	releaseFields(managers, manager, set)
+	return managers
+}
+
+// releaseFields removes the fields in set, and everything under them,
+// from the fields of manager, and returns the removed fields.
+func releaseFields(managers fieldpath.ManagedFields, manager string, set *fieldpath.Set) *fieldpath.Set {
+	released := fieldpath.NewSet()
+	managerSet, ok := managers[manager]
+	if !ok {
+		return released
+	}
+	managerSet.Set().Iterate(func(p fieldpath.Path) {
+		if hasPrefix(set, p) {
+			released.Insert(p.Copy())
+		}
+	})
+	managers[manager] = withSet(managerSet, managerSet.Set().Difference(released))
+	if managers[manager].Set().Empty() {
+		delete(managers, manager)
+	}
+	return released
+}
@@ -0,0 +1,450 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge_test
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	. "sigs.k8s.io/structured-merge-diff/v4/internal/fixture"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+)
+
+func TestTransferOwnership(t *testing.T) {
+	tests := map[string]TestCase{
+		"rename_controller": {
+			Ops: []Operation{
+				Update{
+					Manager:    "old-controller",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "a"
+					`,
+				},
+				TransferOwnership{
+					From:   "old-controller",
+					To:     "new-controller",
+					Fields: _NS(_P("numeric"), _P("string")),
+				},
+			},
+			Object: `
+				numeric: 1
+				string: "a"
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"new-controller": fieldpath.NewVersionedSet(
+					_NS(_P("numeric"), _P("string")),
+					"v1",
+					false,
+				),
+			},
+		},
+		"client_side_to_server_side_apply": {
+			Ops: []Operation{
+				Update{
+					Manager:    "kubectl-client-side-apply",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "a"
+					`,
+				},
+				Apply{
+					Manager:    "kubectl",
+					APIVersion: "v1",
+					Object: `
+						string: "a"
+					`,
+				},
+				TransferOwnership{
+					From:   "kubectl-client-side-apply",
+					To:     "kubectl",
+					Fields: _NS(_P("numeric"), _P("string")),
+				},
+				// The applier now prunes what it no longer applies.
+				Apply{
+					Manager:    "kubectl",
+					APIVersion: "v1",
+					Object: `
+						string: "a"
+					`,
+				},
+			},
+			Object: `
+				string: "a"
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"kubectl": fieldpath.NewVersionedSet(
+					_NS(_P("string")),
+					"v1",
+					true,
+				),
+			},
+		},
+		"release_keeps_values": {
+			Ops: []Operation{
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						numeric: 1
+						string: "a"
+					`,
+				},
+				ReleaseOwnership{
+					Manager: "apply-one",
+					Fields:  _NS(_P("numeric")),
+				},
+				Apply{
+					Manager:    "apply-one",
+					APIVersion: "v1",
+					Object: `
+						string: "a"
+					`,
+				},
+			},
+			Object: `
+				numeric: 1
+				string: "a"
+			`,
+			APIVersion: "v1",
+			Managed: fieldpath.ManagedFields{
+				"apply-one": fieldpath.NewVersionedSet(
+					_NS(_P("string")),
+					"v1",
+					true,
+				),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := test.Test(leafFieldsParser); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestTransferOwnershipToNewManager(t *testing.T) {
+	state := State{
+		Updater: &merge.Updater{Converter: renamingConverter{leafFieldsParser}},
+		Parser:  leafFieldsParser,
+	}
+	if err := state.Apply("numeric: 1\nstring: a\n", "v1", "apply-one", false); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	managers, err := state.Updater.TransferOwnership(state.Live, state.Managers, "apply-one", "controller", _NS(_P("numeric")))
+	if err != nil {
+		t.Fatalf("failed to transfer ownership: %v", err)
+	}
+	// The new manager didn't apply anything, unlike the previous one.
+	expected := fieldpath.ManagedFields{
+		"apply-one":  fieldpath.NewVersionedSet(_NS(_P("string")), "v1", true),
+		"controller": fieldpath.NewVersionedSet(_NS(_P("numeric")), "v1", false),
+	}
+	if !managers.Equals(expected) {
+		t.Errorf("expected managers:\n%v\ngot:\n%v", expected, managers)
+	}
+}
+
+func TestTransferOwnershipAcrossVersions(t *testing.T) {
+	test := TestCase{
+		Ops: []Operation{
+			Apply{
+				Manager:    "apply-one",
+				APIVersion: "v1",
+				Object: `
+					struct:
+					  name: a
+					  scalarField_v1: a
+				`,
+			},
+			Apply{
+				Manager:    "apply-two",
+				APIVersion: "v2",
+				Object: `
+					struct:
+					  name: a
+				`,
+			},
+			TransferOwnership{
+				From:   "apply-one",
+				To:     "apply-two",
+				Fields: _NS(_P("struct", "scalarField_v1")),
+			},
+		},
+		Object: `
+			struct:
+			  name: a
+			  scalarField_v2: a
+		`,
+		APIVersion: "v2",
+		Managed: fieldpath.ManagedFields{
+			"apply-one": fieldpath.NewVersionedSet(
+				_NS(_P("struct", "name")),
+				"v1",
+				true,
+			),
+			"apply-two": fieldpath.NewVersionedSet(
+				_NS(_P("struct", "name"), _P("struct", "scalarField_v2")),
+				"v2",
+				true,
+			),
+		},
+	}
+	if err := test.TestWithConverter(structMultiversionParser, renamingConverter{structMultiversionParser}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConvertManagedFields(t *testing.T) {
+	updater := &merge.Updater{Converter: renamingConverter{structMultiversionParser}}
+	live, err := structMultiversionParser.Type("v1").FromYAML(`
+struct:
+  name: a
+  scalarField_v1: a
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	managers := fieldpath.ManagedFields{
+		"apply-one": fieldpath.NewVersionedSet(
+			_NS(_P("struct", "name"), _P("struct", "scalarField_v1")),
+			"v1",
+			true,
+		),
+		"apply-two": fieldpath.NewVersionedSet(
+			_NS(_P("struct", "name")),
+			"v2",
+			true,
+		),
+	}
+	// The managers can't be compared before they are converted.
+	if _, err := managers.OwnersOf(_P("struct", "name"), "v2"); err == nil {
+		t.Error("expected an error for the owners of managers at different versions")
+	}
+	converted, err := updater.ConvertManagedFields(live, managers, "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fieldpath.ManagedFields{
+		"apply-one": fieldpath.NewVersionedSet(
+			_NS(_P("struct", "name"), _P("struct", "scalarField_v2")),
+			"v2",
+			true,
+		),
+		"apply-two": fieldpath.NewVersionedSet(
+			_NS(_P("struct", "name")),
+			"v2",
+			true,
+		),
+	}
+	if !converted.Equals(expected) {
+		t.Fatalf("expected managers:\n%v\ngot:\n%v", expected, converted)
+	}
+	if owners, err := converted.OwnersOf(_P("struct", "name"), "v2"); err != nil || !reflect.DeepEqual(owners, []string{"apply-one", "apply-two"}) {
+		t.Errorf("unexpected owners of struct.name: %v, %v", owners, err)
+	}
+	if overlaps, err := converted.Overlaps("v2"); err != nil || !overlaps.Equals(_NS(_P("struct", "name"))) {
+		t.Errorf("unexpected overlaps: %v, %v", overlaps, err)
+	}
+}
+
+// TestTransferOwnershipWithoutSetConverter transfers fields of a list item
+// to a manager at another version with a Converter that can only convert
+// objects: the manager must not get the item and its key fields too.
+func TestTransferOwnershipWithoutSetConverter(t *testing.T) {
+	test := TestCase{
+		Ops: []Operation{
+			Update{
+				Manager:    "old-controller",
+				APIVersion: "v1",
+				Object: `
+					list:
+					- name: a
+					  value_v1: x
+					  other_v1: o
+				`,
+			},
+			Update{
+				Manager:    "new-controller",
+				APIVersion: "v2",
+				Object: `
+					list:
+					- name: a
+					  value_v2: x
+					  other_v2: o
+					- name: b
+					  value_v2: q
+				`,
+			},
+			TransferOwnership{
+				From:   "old-controller",
+				To:     "new-controller",
+				Fields: _NS(_P("list", _KBF("name", "a"), "value_v1")),
+			},
+		},
+		Object: `
+			list:
+			- name: a
+			  value_v1: x
+			  other_v1: o
+			- name: b
+			  value_v1: q
+		`,
+		APIVersion: "v1",
+		Managed: fieldpath.ManagedFields{
+			"old-controller": fieldpath.NewVersionedSet(
+				_NS(
+					_P("list"),
+					_P("list", _KBF("name", "a")),
+					_P("list", _KBF("name", "a"), "name"),
+					_P("list", _KBF("name", "a"), "other_v1"),
+				),
+				"v1",
+				false,
+			),
+			"new-controller": fieldpath.NewVersionedSet(
+				_NS(
+					_P("list", _KBF("name", "b")),
+					_P("list", _KBF("name", "b"), "name"),
+					_P("list", _KBF("name", "b"), "value_v2"),
+					_P("list", _KBF("name", "a"), "value_v2"),
+				),
+				"v2",
+				false,
+			),
+		},
+	}
+	if err := test.TestWithConverter(keyedListMultiversionParser, renamingConverter{keyedListMultiversionParser}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConvertManagedFieldsWithoutSetConverter(t *testing.T) {
+	updater := &merge.Updater{Converter: renamingConverter{keyedListMultiversionParser}}
+	live, err := keyedListMultiversionParser.Type("v1").FromYAML(`
+list:
+- name: a
+  value_v1: x
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	managers := fieldpath.ManagedFields{
+		"apply-one": fieldpath.NewVersionedSet(
+			_NS(
+				_P("list", _KBF("name", "a")),
+				_P("list", _KBF("name", "a"), "name"),
+			),
+			"v1",
+			true,
+		),
+		"controller": fieldpath.NewVersionedSet(
+			_NS(_P("list", _KBF("name", "a"), "value_v1")),
+			"v1",
+			false,
+		),
+		// The key fields of an item are converted with it, and can't be
+		// left out.
+		"item-owner": fieldpath.NewVersionedSet(
+			_NS(_P("list", _KBF("name", "a"))),
+			"v1",
+			false,
+		),
+	}
+	converted, err := updater.ConvertManagedFields(live, managers, "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fieldpath.ManagedFields{
+		"apply-one": fieldpath.NewVersionedSet(
+			_NS(
+				_P("list", _KBF("name", "a")),
+				_P("list", _KBF("name", "a"), "name"),
+			),
+			"v2",
+			true,
+		),
+		"controller": fieldpath.NewVersionedSet(
+			_NS(_P("list", _KBF("name", "a"), "value_v2")),
+			"v2",
+			false,
+		),
+		"item-owner": fieldpath.NewVersionedSet(
+			_NS(
+				_P("list", _KBF("name", "a")),
+				_P("list", _KBF("name", "a"), "name"),
+			),
+			"v2",
+			false,
+		),
+	}
+	if !converted.Equals(expected) {
+		t.Fatalf("expected managers:\n%v\ngot:\n%v", expected, converted)
+	}
+	// The managers that own the item own its fields too.
+	if owners, err := converted.OwnersOf(_P("list", _KBF("name", "a"), "value_v2"), "v2"); err != nil || !reflect.DeepEqual(owners, []string{"apply-one", "controller", "item-owner"}) {
+		t.Errorf("unexpected owners of the value field: %v, %v", owners, err)
+	}
+}
+
+func TestUnowned(t *testing.T) {
+	updater := &merge.Updater{Converter: renamingConverter{keyedListMultiversionParser}}
+	live, err := keyedListMultiversionParser.Type("v2").FromYAML(`
+list:
+- name: a
+  value_v2: a
+- name: b
+  value_v2: b
+  other_v2: b
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Owning an item owns the fields of the item too.
+	managers := fieldpath.ManagedFields{
+		"apply-one": fieldpath.NewVersionedSet(
+			_NS(_P("list", _KBF("name", "a"))),
+			"v1",
+			true,
+		),
+		"apply-two": fieldpath.NewVersionedSet(
+			_NS(_P("list", _KBF("name", "b"), "value_v2")),
+			"v2",
+			true,
+		),
+	}
+	unowned, err := updater.Unowned(live, managers, "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := _NS(
+		_P("list", _KBF("name", "b")),
+		_P("list", _KBF("name", "b"), "name"),
+		_P("list", _KBF("name", "b"), "other_v2"),
+	)
+	if !unowned.Equals(expected) {
+		t.Errorf("expected unowned fields:\n%v\ngot:\n%v", expected, unowned)
+	}
+}
@@ -15,6 +15,8 @@ package merge
 
 import (
 	"fmt"
+	"reflect"
+	"time"
 
 	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
@@ -27,32 +29,111 @@ type Converter interface {
 	IsMissingVersionError(error) bool
 }
 
+// SetConverter can optionally be implemented by a Converter to convert
+// sets of fields from one version to another directly. The Updater then
+// uses it to find the fields changed in the version of each manager,
+// rather than converting the objects to every version.
+type SetConverter interface {
+	ConvertSet(set *fieldpath.Set, from, to fieldpath.APIVersion) (*fieldpath.Set, error)
+}
+
 // Updater is the object used to compute updated FieldSets and also
 // merge the object on Apply.
 type Updater struct {
 	Converter     Converter
 	IgnoredFields map[fieldpath.APIVersion]*fieldpath.Set
 
-	enableUnions bool
+	// Sharing, if set, lists the fields that managers share rather
+	// than lose when another manager changes them.
+	Sharing SharingPolicy
+
+	// Permissions, if set, restrict the fields that managers may own.
+	// Operations that would give a manager fields that it may not own
+	// fail with a PermissionError.
+	Permissions Permissions
+
+	// Observer, if set, is notified of what operations do to the
+	// managers, e.g. to record events.
+	Observer *Observer
+	// observation holds the events of the current operation, if any.
+	observation *observation
+
+	// Now returns the current time, which is recorded in the metadata
+	// of the manager that updates or applies the object, unless the
+	// operation leaves its entry as it was (see fieldpath.ManagerMetadata).
+	// It defaults to time.Now. The rest of the metadata of managers is
+	// kept as is.
+	Now func() time.Time
 }
 
-// EnableUnionFeature turns on union handling. It is disabled by default until the
-// feature is complete.
-func (s *Updater) EnableUnionFeature() {
-	s.enableUnions = true
+// operationEntry returns the entry of a manager that updates or applies
+// the object with the given options, given its previous entry, if any,
+// and its new fields. Its time is the current one, unless the operation
+// changes neither its fields, their version, whether they were applied,
+// its deleted fields nor its labels, so that operations which change
+// nothing don't change the managers either.
+func (s *Updater) operationEntry(previous fieldpath.VersionedSet, set *fieldpath.Set, version fieldpath.APIVersion, applied bool, contested, deleted *fieldpath.Set, options Options) fieldpath.VersionedSet {
+	var metadata fieldpath.ManagerMetadata
+	if previous != nil {
+		metadata = fieldpath.MetadataOf(previous)
+	}
+	unchanged := previous != nil &&
+		previous.APIVersion() == version &&
+		previous.Applied() == applied &&
+		previous.Set().Equals(set) &&
+		metadata.Deleted.Equals(setOrEmpty(deleted)) &&
+		(options.Labels == nil || reflect.DeepEqual(options.Labels, metadata.Labels))
+	if !unchanged {
+		now := time.Now
+		if s.Now != nil {
+			now = s.Now
+		}
+		metadata.Time = now()
+	}
+	if options.Scope != nil {
+		metadata.Subresource = options.Scope.Subresource
+	}
+	if options.Labels != nil {
+		metadata.Labels = options.Labels
+	}
+	metadata.Contested = contested
+	metadata.Deleted = deleted
+	return fieldpath.NewVersionedSetWithMetadata(set, version, applied, metadata)
 }
 
-func (s *Updater) update(oldObject, newObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, workflow string, force bool) (fieldpath.ManagedFields, *typed.Comparison, error) {
+// EnableUnionFeature used to turn on union handling, which is now always
+// on.
+//
+// Deprecated: unions are always normalized.
+func (s *Updater) EnableUnionFeature() {}
+
+// update computes the new managed fields after oldObject has been changed
+// to newObject by workflow, and the conflicts that were forced. Fields
+// owned by other managers that were added or modified are conflicts, as
+// well as removed fields under the deletions of workflow, given at
+// version. Other removals, such as those of union normalization, aren't
+// conflicts. Unless force is set, only the conflicts on the fields in
+// forced, given at version, are forced.
+func (s *Updater) update(oldObject, newObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, workflow string, force bool, forced, deletions *fieldpath.Set) (fieldpath.ManagedFields, *typed.Comparison, fieldpath.ManagedFields, error) {
 	conflicts := fieldpath.ManagedFields{}
+	contested := fieldpath.ManagedFields{}
 	removed := fieldpath.ManagedFields{}
 	compare, err := oldObject.Compare(newObject)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to compare objects: %v", err)
+		return nil, nil, nil, fmt.Errorf("failed to compare objects: %v", err)
 	}
 
+	// ExcludeFields modifies the comparison, so keep the unfiltered sets
+	// to convert them to other versions.
+	changes := &typed.Comparison{Removed: compare.Removed, Modified: compare.Modified, Added: compare.Added}
 	versions := map[fieldpath.APIVersion]*typed.Comparison{
 		version: compare.ExcludeFields(s.IgnoredFields[version]),
 	}
+	// The removed fields that were deleted, or are under a deleted field.
+	deleted := changes.Removed.Difference(changes.Removed.RecursiveDifference(deletions))
+	deletedVersions := map[fieldpath.APIVersion]*fieldpath.Set{
+		version: deleted,
+	}
 
 	for manager, managerSet := range managers {
 		if manager == workflow {
@@ -61,32 +142,39 @@ func (s *Updater) update(oldObject, newObject *typed.TypedValue, version fieldpa
 		compare, ok := versions[managerSet.APIVersion()]
 		if !ok {
 			var err error
-			versionedOldObject, err := s.Converter.Convert(oldObject, managerSet.APIVersion())
-			if err != nil {
-				if s.Converter.IsMissingVersionError(err) {
-					delete(managers, manager)
-					continue
-				}
-				return nil, nil, fmt.Errorf("failed to convert old object: %v", err)
-			}
-			versionedNewObject, err := s.Converter.Convert(newObject, managerSet.APIVersion())
+			compare, err = s.versionedComparison(changes, oldObject, newObject, version, managerSet.APIVersion())
 			if err != nil {
 				if s.Converter.IsMissingVersionError(err) {
+					s.observeRemoved(manager, managerSet)
 					delete(managers, manager)
 					continue
 				}
-				return nil, nil, fmt.Errorf("failed to convert new object: %v", err)
-			}
-			compare, err = versionedOldObject.Compare(versionedNewObject)
-			if err != nil {
-				return nil, nil, fmt.Errorf("failed to compare objects: %v", err)
+				return nil, nil, nil, err
 			}
 			versions[managerSet.APIVersion()] = compare.ExcludeFields(s.IgnoredFields[managerSet.APIVersion()])
 		}
 
 		conflictSet := managerSet.Set().Intersection(compare.Modified.Union(compare.Added))
+		if shared := s.sharedFields(manager, fieldpath.NewVersionedSet(conflictSet, managerSet.APIVersion(), managerSet.Applied())); !shared.Empty() {
+			conflictSet = conflictSet.Difference(shared)
+			contested[manager] = fieldpath.NewVersionedSet(shared, managerSet.APIVersion(), managerSet.Applied())
+		}
+		if !deleted.Empty() {
+			deletedSet, ok := deletedVersions[managerSet.APIVersion()]
+			if !ok {
+				var err error
+				deletedSet, err = s.convertFields(deleted, version, managerSet.APIVersion(), oldObject)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				deletedVersions[managerSet.APIVersion()] = deletedSet
+			}
+			// Removing a field removes everything under it too.
+			owned := managerSet.Set()
+			conflictSet = conflictSet.Union(owned.Difference(owned.RecursiveDifference(deletedSet)))
+		}
 		if !conflictSet.Empty() {
-			conflicts[manager] = fieldpath.NewVersionedSet(conflictSet, managerSet.APIVersion(), false)
+			conflicts[manager] = fieldpath.NewVersionedSet(conflictSet, managerSet.APIVersion(), managerSet.Applied())
 		}
 
 		if !compare.Removed.Empty() {
@@ -95,24 +183,121 @@ func (s *Updater) update(oldObject, newObject *typed.TypedValue, version fieldpa
 	}
 
 	if !force && len(conflicts) != 0 {
-		return nil, nil, ConflictsFromManagers(conflicts)
+		if err := s.unforcedConflicts(conflicts, forced, version, oldObject, newObject); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	for manager, contestedSet := range contested {
+		managerSet := managers[manager]
+		metadata := fieldpath.MetadataOf(managerSet)
+		metadata.Contested = metadata.Contested.Union(contestedSet.Set())
+		managers[manager] = fieldpath.NewVersionedSetWithMetadata(managerSet.Set(), managerSet.APIVersion(), managerSet.Applied(), metadata)
 	}
 
 	for manager, conflictSet := range conflicts {
-		managers[manager] = fieldpath.NewVersionedSet(managers[manager].Set().Difference(conflictSet.Set()), managers[manager].APIVersion(), managers[manager].Applied())
+		managers[manager] = withSet(managers[manager], managers[manager].Set().Difference(conflictSet.Set()))
+	}
+	if len(conflicts) != 0 {
+		taken := conflicts
+		s.observe(func(o *Observer) {
+			if o.Taken != nil {
+				o.Taken(managerName(workflow), taken)
+			}
+		})
 	}
 
 	for manager, removedSet := range removed {
-		managers[manager] = fieldpath.NewVersionedSet(managers[manager].Set().Difference(removedSet.Set()), managers[manager].APIVersion(), managers[manager].Applied())
+		managers[manager] = withSet(managers[manager], managers[manager].Set().Difference(removedSet.Set()))
 	}
 
 	for manager := range managers {
-		if managers[manager].Set().Empty() {
+		if managers[manager].Set().Empty() && fieldpath.MetadataOf(managers[manager]).Deleted.Empty() {
 			delete(managers, manager)
 		}
 	}
 
-	return managers, compare, nil
+	return managers, compare, conflicts, nil
+}
+
+// versionedComparison returns the fields changed from oldObject to
+// newObject, in the version to. changes are the fields changed in the
+// version from, which are converted directly if the Converter is a
+// SetConverter. Otherwise both objects are converted and compared.
+func (s *Updater) versionedComparison(changes *typed.Comparison, oldObject, newObject *typed.TypedValue, from, to fieldpath.APIVersion) (*typed.Comparison, error) {
+	if sc, ok := s.Converter.(SetConverter); ok {
+		out := &typed.Comparison{}
+		for _, set := range []struct {
+			in   *fieldpath.Set
+			out  **fieldpath.Set
+			name string
+		}{
+			{changes.Removed, &out.Removed, "removed"},
+			{changes.Modified, &out.Modified, "modified"},
+			{changes.Added, &out.Added, "added"},
+		} {
+			converted, err := sc.ConvertSet(set.in, from, to)
+			if err != nil {
+				if s.Converter.IsMissingVersionError(err) {
+					return nil, err
+				}
+				return nil, fmt.Errorf("failed to convert %v fields: %v", set.name, err)
+			}
+			*set.out = converted
+		}
+		return out, nil
+	}
+	versionedOldObject, err := s.Converter.Convert(oldObject, to)
+	if err != nil {
+		if s.Converter.IsMissingVersionError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to convert old object: %v", err)
+	}
+	versionedNewObject, err := s.Converter.Convert(newObject, to)
+	if err != nil {
+		if s.Converter.IsMissingVersionError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to convert new object: %v", err)
+	}
+	compare, err := versionedOldObject.Compare(versionedNewObject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare objects: %v", err)
+	}
+	return compare, nil
+}
+
+// Options are the options of UpdateWithOptions and ApplyWithOptions. The
+// zero value gives a plain operation. Forced, Deletions and Plan are
+// specific to applies, and updates fail if they are set.
+type Options struct {
+	// Forced, if set, lists the fields, given at the version of the
+	// apply along with everything under them, whose conflicts are
+	// forced. The other conflicts are still returned as errors. It is
+	// ignored if the apply is forced.
+	Forced *fieldpath.Set
+	// Deletions, if set, lists the fields and list or map items, given
+	// at the version of the apply, that the applier explicitly deletes,
+	// along with everything under them. Deleting something owned by
+	// another manager is a conflict, and when forced, that manager loses
+	// the ownership of what was deleted. The configuration can't set
+	// what it deletes.
+	Deletions *fieldpath.Set
+	// Scope, if set, restricts the operation to some fields of the
+	// object. The operation fails if it would change fields outside of
+	// the scope.
+	Scope *Scope
+	// Labels, if not nil, replace the labels in the metadata of the
+	// manager (see fieldpath.MetadataOf). They are kept as is otherwise.
+	Labels map[string]string
+	// Plan, if set, is filled with the details of the apply. It is
+	// filled even if the apply fails because of conflicts, and then
+	// describes the apply as if they were forced.
+	Plan *ApplyPlan
+	// DryRun is set if the result of the operation won't be kept, in
+	// which case the Observer isn't notified.
+	DryRun bool
 }
 
 // Update is the method you should call once you've merged your final
@@ -121,18 +306,39 @@ func (s *Updater) update(oldObject, newObject *typed.TypedValue, version fieldpa
 // PATCH call), and liveObject must be the original object (empty if
 // this is a CREATE call).
 func (s *Updater) Update(liveObject, newObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	return s.UpdateWithOptions(liveObject, newObject, version, managers, manager, Options{})
+}
+
+// UpdateWithOptions is like Update, with the given options. It fails if
+// options that are specific to applies are set.
+func (s *Updater) UpdateWithOptions(liveObject, newObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string, options Options) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	if options.Forced != nil || options.Deletions != nil || options.Plan != nil {
+		return nil, fieldpath.ManagedFields{}, fmt.Errorf("Forced, Deletions and Plan are only options of applies")
+	}
 	var err error
+	s = s.withConversionCache().observing(options.DryRun)
 	managers, err = s.reconcileManagedFieldsWithSchemaChanges(liveObject, managers)
 	if err != nil {
 		return nil, fieldpath.ManagedFields{}, err
 	}
-	if s.enableUnions {
-		newObject, err = liveObject.NormalizeUnions(newObject)
+	newObject, err = liveObject.NormalizeUnions(newObject)
+	if err != nil {
+		return nil, fieldpath.ManagedFields{}, err
+	}
+	if options.Scope != nil {
+		// The fields changed by normalizing unions must be in the
+		// scope too.
+		compare, err := liveObject.Compare(newObject)
 		if err != nil {
-			return nil, fieldpath.ManagedFields{}, err
+			return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to compare objects: %v", err)
+		}
+		if outside := options.Scope.outside(compare.Modified.Union(compare.Added).Union(compare.Removed)); !outside.Empty() {
+			return nil, fieldpath.ManagedFields{}, fmt.Errorf("fields outside of the %q scope can't be changed:\n%v", options.Scope.Subresource, outside)
 		}
+		manager = fieldpath.ScopedManager(manager, options.Scope.Subresource)
 	}
-	managers, compare, err := s.update(liveObject, newObject, version, managers, manager, true)
+	previous := managers[manager]
+	managers, compare, _, err := s.update(liveObject, newObject, version, managers, manager, true, nil, fieldpath.NewSet())
 	if err != nil {
 		return nil, fieldpath.ManagedFields{}, err
 	}
@@ -144,14 +350,27 @@ func (s *Updater) Update(liveObject, newObject *typed.TypedValue, version fieldp
 	if ignored == nil {
 		ignored = fieldpath.NewSet()
 	}
-	managers[manager] = fieldpath.NewVersionedSet(
+	if err := s.checkPermissions(manager, version, compare.Modified.Union(compare.Added).RecursiveDifference(ignored)); err != nil {
+		return nil, fieldpath.ManagedFields{}, err
+	}
+	// The fields the manager changed aren't contested anymore.
+	var contested *fieldpath.Set
+	if managers[manager].APIVersion() == version {
+		contested = fieldpath.MetadataOf(managers[manager]).Contested.Difference(compare.Modified).Difference(compare.Added)
+	}
+	managers[manager] = s.operationEntry(
+		previous,
 		managers[manager].Set().Union(compare.Modified).Union(compare.Added).Difference(compare.Removed).RecursiveDifference(ignored),
 		version,
 		false,
+		contested,
+		nil,
+		options,
 	)
 	if managers[manager].Set().Empty() {
 		delete(managers, manager)
 	}
+	s.notify()
 	return newObject, managers, nil
 }
 
@@ -160,26 +379,49 @@ func (s *Updater) Update(liveObject, newObject *typed.TypedValue, version fieldp
 // and return it. If the object hasn't changed, nil is returned (the
 // managers can still have changed though).
 func (s *Updater) Apply(liveObject, configObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string, force bool) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	return s.ApplyWithOptions(liveObject, configObject, version, managers, manager, force, Options{})
+}
+
+// ApplyWithOptions is like Apply, with the given options.
+func (s *Updater) ApplyWithOptions(liveObject, configObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string, force bool, options Options) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	if options.Scope != nil {
+		manager = fieldpath.ScopedManager(manager, options.Scope.Subresource)
+	}
+	return s.apply(liveObject, configObject, version, managers, manager, force, options)
+}
+
+// setOrEmpty returns set, or an empty set if it is nil.
+func setOrEmpty(set *fieldpath.Set) *fieldpath.Set {
+	if set == nil {
+		return fieldpath.NewSet()
+	}
+	return set
+}
+
+// apply implements Apply.
+func (s *Updater) apply(liveObject, configObject *typed.TypedValue, version fieldpath.APIVersion, managers fieldpath.ManagedFields, manager string, force bool, options Options) (*typed.TypedValue, fieldpath.ManagedFields, error) {
+	deletions := setOrEmpty(options.Deletions)
+	plan := options.Plan
+	if plan != nil {
+		*plan = ApplyPlan{Pruned: fieldpath.NewSet()}
+	}
+	s = s.withConversionCache().observing(options.DryRun)
 	var err error
 	managers, err = s.reconcileManagedFieldsWithSchemaChanges(liveObject, managers)
 	if err != nil {
 		return nil, fieldpath.ManagedFields{}, err
 	}
-	if s.enableUnions {
-		configObject, err = configObject.NormalizeUnionsApply(configObject)
-		if err != nil {
-			return nil, fieldpath.ManagedFields{}, err
-		}
+	configObject, err = configObject.NormalizeUnionsApply(configObject)
+	if err != nil {
+		return nil, fieldpath.ManagedFields{}, err
 	}
 	newObject, err := liveObject.Merge(configObject)
 	if err != nil {
 		return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to merge config: %v", err)
 	}
-	if s.enableUnions {
-		newObject, err = configObject.NormalizeUnionsApply(newObject)
-		if err != nil {
-			return nil, fieldpath.ManagedFields{}, err
-		}
+	newObject, err = configObject.NormalizeUnionsApply(newObject)
+	if err != nil {
+		return nil, fieldpath.ManagedFields{}, err
 	}
 	lastSet := managers[manager]
 	set, err := configObject.ToFieldSet()
@@ -195,21 +437,116 @@ func (s *Updater) Apply(liveObject, configObject *typed.TypedValue, version fiel
 			lastSet.Set().RecursiveDifference(ignored)
 		}
 	}
-	managers[manager] = fieldpath.NewVersionedSet(set, version, true)
+	if both := set.Difference(set.RecursiveDifference(deletions)); !both.Empty() {
+		return nil, fieldpath.ManagedFields{}, fmt.Errorf("fields can't be both applied and deleted:\n%v", both)
+	}
+	if options.Scope != nil {
+		// The configuration is checked once its unions are normalized.
+		if outside := options.Scope.outside(set.Union(deletions)); !outside.Empty() {
+			return nil, fieldpath.ManagedFields{}, fmt.Errorf("fields outside of the %q scope can't be applied:\n%v", options.Scope.Subresource, outside)
+		}
+	}
+	checked := set
+	if !deletions.Empty() && len(s.Permissions) != 0 {
+		// Deleting a field deletes everything under it too, which the
+		// manager must be allowed to own.
+		liveSet, err := liveObject.ToFieldSet()
+		if err != nil {
+			return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to get live fields: %v", err)
+		}
+		checked = set.Union(deletions).Union(liveSet.Difference(liveSet.RecursiveDifference(deletions)))
+	}
+	if err := s.checkPermissions(manager, version, checked); err != nil {
+		return nil, fieldpath.ManagedFields{}, err
+	}
+	if plan != nil {
+		plan.previous = managers.Copy()
+	}
+	var deleted *fieldpath.Set
+	if !deletions.Empty() {
+		deleted = deletions
+	}
+	managers[manager] = s.operationEntry(lastSet, set, version, true, nil, deleted, options)
+	merged := newObject
 	newObject, err = s.prune(newObject, managers, manager, lastSet)
 	if err != nil {
 		return nil, fieldpath.ManagedFields{}, fmt.Errorf("failed to prune fields: %v", err)
 	}
-	managers, compare, err := s.update(liveObject, newObject, version, managers, manager, force)
+	if plan != nil || (s.Observer != nil && s.Observer.Pruned != nil) {
+		pruned, err := s.prunedFields(merged, newObject, lastSet)
+		if err != nil {
+			return nil, fieldpath.ManagedFields{}, err
+		}
+		if plan != nil && lastSet != nil {
+			plan.Pruned = pruned
+			plan.PrunedVersion = lastSet.APIVersion()
+		}
+		if !pruned.Empty() {
+			s.observe(func(o *Observer) {
+				if o.Pruned != nil {
+					o.Pruned(managerName(manager), pruned, lastSet)
+				}
+			})
+		}
+	}
+	// Deletions come after pruning, which adds back the items that other
+	// managers own.
+	if !deletions.Empty() {
+		newObject = newObject.RemoveItems(deletions)
+	}
+	// A plan describes the apply as if it was forced, and has every
+	// conflict.
+	managers, compare, conflicts, err := s.update(liveObject, newObject, version, managers, manager, force || plan != nil, options.Forced, deletions)
+	if err == nil && plan != nil {
+		plan.Object = newObject
+		plan.Managers = managers
+		plan.Conflicts = s.conflictsWithValues(liveObject, newObject, conflicts)
+		plan.Changed = !compare.IsSame()
+		plan.setMoved(manager)
+		if !force {
+			err = s.unforcedConflicts(conflicts, options.Forced, version, liveObject, newObject)
+		}
+	}
 	if err != nil {
+		if conflicts, ok := err.(Conflicts); ok {
+			s.observeConflicts(manager, conflicts)
+		}
 		return nil, fieldpath.ManagedFields{}, err
 	}
+	s.notify()
 	if compare.IsSame() {
 		newObject = nil
 	}
 	return newObject, managers, nil
 }
 
+// ExtractApplied returns the part of the live object that is owned by the
+// given manager, converted to the version that manager last used. The
+// result contains every field the manager owns, including the key fields
+// of owned list items, and can be used as the manager's next applied
+// configuration. If the manager doesn't own anything, an empty object is
+// returned. If the version of the manager doesn't exist anymore, the error
+// of the Converter is returned as is, so that it can be recognized with
+// IsMissingVersionError.
+func (s *Updater) ExtractApplied(liveObject *typed.TypedValue, managers fieldpath.ManagedFields, manager string) (*typed.TypedValue, error) {
+	managerSet, ok := managers[manager]
+	if !ok {
+		return liveObject.ExtractItems(fieldpath.NewSet())
+	}
+	versionedLive, err := s.Converter.Convert(liveObject, managerSet.APIVersion())
+	if err != nil {
+		if s.Converter.IsMissingVersionError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to convert live object to version %v: %v", managerSet.APIVersion(), err)
+	}
+	extracted, err := versionedLive.ExtractItems(managerSet.Set())
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract fields of %q: %v", manager, err)
+	}
+	return extracted, nil
+}
+
 // prune will remove a field, list or map item, iff:
 // * applyingManager applied it last time
 // * applyingManager didn't apply it this time
@@ -316,6 +653,7 @@ func (s *Updater) reconcileManagedFieldsWithSchemaChanges(liveObject *typed.Type
 	for manager, versionedSet := range managers {
 		tv, err := s.Converter.Convert(liveObject, versionedSet.APIVersion())
 		if s.Converter.IsMissingVersionError(err) { // okay to skip, obsolete versions will be deleted automatically anyway
+			s.observeRemoved(manager, versionedSet)
 			continue
 		}
 		if err != nil {
@@ -326,7 +664,7 @@ func (s *Updater) reconcileManagedFieldsWithSchemaChanges(liveObject *typed.Type
 			return nil, err
 		}
 		if reconciled != nil {
-			result[manager] = fieldpath.NewVersionedSet(reconciled, versionedSet.APIVersion(), versionedSet.Applied())
+			result[manager] = withSet(versionedSet, reconciled)
 		} else {
 			result[manager] = versionedSet
 		}
@@ -198,6 +198,10 @@ type StructField struct {
 	Type TypeRef `yaml:"type,omitempty"`
 	// Default value for the field, nil if not present.
 	Default interface{} `yaml:"default,omitempty"`
+	// Sensitive is true if the field holds secrets, like passwords or
+	// tokens. The values of sensitive fields are redacted when a value
+	// is printed or logged.
+	Sensitive bool `yaml:"sensitive,omitempty"`
 }
 
 // List represents a type which contains a zero or more elements, all of the
@@ -170,6 +170,9 @@ func (a *StructField) Equals(b *StructField) bool {
 	if a.Name != b.Name {
 		return false
 	}
+	if a.Sensitive != b.Sensitive {
+		return false
+	}
 	if !reflect.DeepEqual(a.Default, b.Default) {
 		return false
 	}
@@ -128,6 +128,9 @@ var SchemaSchemaYAML = `types:
     - name: default
       type:
         namedType: __untyped_atomic_
+    - name: sensitive
+      type:
+        scalar: boolean
 - name: list
   map:
     fields:
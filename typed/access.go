@@ -0,0 +1,326 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// accessor resolves path elements against a value and its schema. Writes
+// never modify the original value: the containers along the path are
+// copied, and everything else is shared with the original.
+type accessor struct {
+	schema    *schema.Schema
+	allocator value.Allocator
+}
+
+func (tv TypedValue) accessor() *accessor {
+	return &accessor{
+		schema:    tv.schema,
+		allocator: value.NewFreelistAllocator(),
+	}
+}
+
+// resolve returns the atom for the given type, narrowed down to the kind
+// of value v is.
+func (a *accessor) resolve(tr schema.TypeRef, v value.Value) (schema.Atom, error) {
+	atom, ok := a.schema.Resolve(tr)
+	if !ok {
+		return schema.Atom{}, kindError(SchemaError, "schema error: no type found matching: %v", *tr.NamedType)
+	}
+	return deduceAtom(atom, v), nil
+}
+
+// fieldType returns the type of the given field in a map, or an error if
+// the field isn't allowed in that map.
+func fieldType(m *schema.Map, name string) (schema.TypeRef, error) {
+	if sf, ok := m.FindField(name); ok {
+		return sf.Type, nil
+	}
+	if (m.ElementType == schema.TypeRef{}) {
+		return schema.TypeRef{}, kindError(UnknownFieldError, "field not declared in schema")
+	}
+	return m.ElementType, nil
+}
+
+// findItem returns the index of the list item matching pe, or -1 if
+// there is none.
+func (a *accessor) findItem(t *schema.List, l value.List, pe fieldpath.PathElement) (int, error) {
+	if pe.Index != nil {
+		if t.ElementRelationship == schema.Associative {
+			return -1, fmt.Errorf("index path element used on associative list")
+		}
+		if *pe.Index < 0 || *pe.Index >= l.Length() {
+			return -1, nil
+		}
+		return *pe.Index, nil
+	}
+	if t.ElementRelationship != schema.Associative {
+		return -1, fmt.Errorf("path element %v requires an associative list", pe)
+	}
+	if pe.Key != nil && len(t.Keys) == 0 {
+		return -1, fmt.Errorf("key path element used on a list without keys")
+	}
+	if pe.Value != nil && len(t.Keys) != 0 {
+		return -1, fmt.Errorf("value path element used on a list with keys")
+	}
+	for i := 0; i < l.Length(); i++ {
+		child := l.AtUsing(a.allocator, i)
+		ipe, err := listItemToPathElement(a.allocator, a.schema, t, i, child)
+		if err != nil {
+			a.allocator.Free(child)
+			return -1, fmt.Errorf("element %v: %v", i, err)
+		}
+		// Set items reference the child, so compare before freeing it.
+		found := ipe.Equals(pe)
+		a.allocator.Free(child)
+		if found {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// get returns the value found at path, if any.
+func (a *accessor) get(v value.Value, tr schema.TypeRef, path fieldpath.Path) (value.Value, schema.TypeRef, bool, error) {
+	if len(path) == 0 {
+		return v, tr, true, nil
+	}
+	if v == nil || v.IsNull() {
+		return nil, tr, false, nil
+	}
+	atom, err := a.resolve(tr, v)
+	if err != nil {
+		return nil, tr, false, err
+	}
+	pe := path[0]
+	switch {
+	case pe.FieldName != nil:
+		if atom.Map == nil || !v.IsMap() {
+			return nil, tr, false, fmt.Errorf("field path element used on a non-map value")
+		}
+		ft, err := fieldType(atom.Map, *pe.FieldName)
+		if err != nil {
+			return nil, tr, false, err
+		}
+		child, ok := v.AsMap().Get(*pe.FieldName)
+		if !ok {
+			return nil, ft, false, nil
+		}
+		return a.get(child, ft, path[1:])
+	default:
+		if atom.List == nil || !v.IsList() {
+			return nil, tr, false, fmt.Errorf("list path element used on a non-list value")
+		}
+		l := v.AsList()
+		i, err := a.findItem(atom.List, l, pe)
+		if err != nil || i < 0 {
+			return nil, atom.List.ElementType, false, err
+		}
+		return a.get(l.At(i), atom.List.ElementType, path[1:])
+	}
+}
+
+// set returns a copy of v where the value at path has been replaced by
+// newValue. Missing containers along the path are created.
+func (a *accessor) set(v value.Value, tr schema.TypeRef, path fieldpath.Path, newValue value.Value) (interface{}, error) {
+	if len(path) == 0 {
+		return newValue.Unstructured(), nil
+	}
+	atom, err := a.resolve(tr, v)
+	if err != nil {
+		return nil, err
+	}
+	pe := path[0]
+	if pe.FieldName != nil {
+		if atom.Map == nil {
+			return nil, fmt.Errorf("field path element used on a non-map type")
+		}
+		ft, err := fieldType(atom.Map, *pe.FieldName)
+		if err != nil {
+			return nil, err
+		}
+		out, err := a.copyMap(v)
+		if err != nil {
+			return nil, err
+		}
+		var child value.Value
+		if c, ok := out[*pe.FieldName]; ok {
+			child = value.NewValueInterface(c)
+		}
+		newChild, err := a.set(child, ft, path[1:], newValue)
+		if err != nil {
+			return nil, err
+		}
+		out[*pe.FieldName] = newChild
+		return out, nil
+	}
+
+	if atom.List == nil {
+		return nil, fmt.Errorf("list path element used on a non-list type")
+	}
+	out, err := a.copyList(v)
+	if err != nil {
+		return nil, err
+	}
+	i, err := a.findItem(atom.List, value.NewValueInterface(out).AsList(), pe)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 {
+		if pe.Index != nil {
+			return nil, fmt.Errorf("index %v out of range", *pe.Index)
+		}
+		// New items of associative lists are appended, starting from
+		// their key fields.
+		var child value.Value
+		if pe.Key != nil && len(path) > 1 {
+			item := map[string]interface{}{}
+			for _, f := range *pe.Key {
+				item[f.Name] = f.Value.Unstructured()
+			}
+			child = value.NewValueInterface(item)
+		}
+		newChild, err := a.set(child, atom.List.ElementType, path[1:], newValue)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, newChild)
+		i = len(out) - 1
+	} else {
+		newChild, err := a.set(value.NewValueInterface(out[i]), atom.List.ElementType, path[1:], newValue)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = newChild
+	}
+	// The item must still be found where it was written.
+	if pe.Index == nil {
+		ipe, err := listItemToPathElement(a.allocator, a.schema, atom.List, i, value.NewValueInterface(out[i]))
+		if err != nil {
+			return nil, err
+		}
+		if !ipe.Equals(pe) {
+			return nil, fmt.Errorf("item written at %v would be found at %v", pe, ipe)
+		}
+	}
+	return out, nil
+}
+
+// remove returns a copy of v where the value at path has been removed.
+// It is not an error for the path to be missing.
+func (a *accessor) remove(v value.Value, tr schema.TypeRef, path fieldpath.Path) (interface{}, error) {
+	if v == nil || v.IsNull() {
+		return nil, nil
+	}
+	atom, err := a.resolve(tr, v)
+	if err != nil {
+		return nil, err
+	}
+	pe := path[0]
+	if pe.FieldName != nil {
+		if atom.Map == nil {
+			return nil, fmt.Errorf("field path element used on a non-map type")
+		}
+		out, err := a.copyMap(v)
+		if err != nil {
+			return nil, err
+		}
+		child, ok := out[*pe.FieldName]
+		if !ok {
+			return out, nil
+		}
+		// Fields that aren't declared can be removed, but not descended
+		// into.
+		if len(path) == 1 {
+			delete(out, *pe.FieldName)
+			return out, nil
+		}
+		ft, err := fieldType(atom.Map, *pe.FieldName)
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := a.remove(value.NewValueInterface(child), ft, path[1:])
+		if err != nil {
+			return nil, err
+		}
+		out[*pe.FieldName] = newChild
+		return out, nil
+	}
+
+	if atom.List == nil {
+		return nil, fmt.Errorf("list path element used on a non-list type")
+	}
+	out, err := a.copyList(v)
+	if err != nil {
+		return nil, err
+	}
+	i, err := a.findItem(atom.List, value.NewValueInterface(out).AsList(), pe)
+	if err != nil || i < 0 {
+		return out, err
+	}
+	if len(path) == 1 {
+		return append(out[:i], out[i+1:]...), nil
+	}
+	newChild, err := a.remove(value.NewValueInterface(out[i]), atom.List.ElementType, path[1:])
+	if err != nil {
+		return nil, err
+	}
+	out[i] = newChild
+	return out, nil
+}
+
+// copyMap makes a shallow copy of the given map value. A nil or null value
+// results in an empty map.
+func (a *accessor) copyMap(v value.Value) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	if v == nil || v.IsNull() {
+		return out, nil
+	}
+	m, err := mapValue(a.allocator, v)
+	if err != nil {
+		return nil, err
+	}
+	defer a.allocator.Free(m)
+	m.Iterate(func(k string, val value.Value) bool {
+		out[k] = val.Unstructured()
+		return true
+	})
+	return out, nil
+}
+
+// copyList makes a shallow copy of the given list value. A nil or null
+// value results in an empty list.
+func (a *accessor) copyList(v value.Value) ([]interface{}, error) {
+	if v == nil || v.IsNull() {
+		return []interface{}{}, nil
+	}
+	l, err := listValue(a.allocator, v)
+	if err != nil {
+		return nil, err
+	}
+	defer a.allocator.Free(l)
+	out := make([]interface{}, 0, l.Length())
+	for i := 0; i < l.Length(); i++ {
+		out = append(out, l.At(i).Unstructured())
+	}
+	return out, nil
+}
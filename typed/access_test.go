@@ -0,0 +1,313 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+const accessObject = typed.YAMLObject(`
+name: a
+labels:
+  a: b
+set: [a, b]
+list:
+- key: a
+  id: 1
+  value: x
+- key: b
+  id: 2
+`)
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		path     fieldpath.Path
+		expected interface{}
+		found    bool
+		invalid  bool
+	}{
+		{path: _P(), expected: nil, found: true},
+		{path: _P("name"), expected: "a", found: true},
+		{path: _P("replicas"), found: false},
+		{path: _P("labels", "a"), expected: "b", found: true},
+		{path: _P("set", _V("b")), expected: "b", found: true},
+		{path: _P("set", _V("c")), found: false},
+		{path: _P("list", _KBF("key", "a", "id", 1), "value"), expected: "x", found: true},
+		{path: _P("list", _KBF("key", "b", "id", 2), "value"), found: false},
+		{path: _P("list", _KBF("key", "b", "id", 3)), found: false},
+		{path: _P("list", 0), invalid: true},
+		{path: _P("unknown"), invalid: true},
+		{path: _P("name", "a"), invalid: true},
+	}
+
+	object, err := extractParser.Type("type").FromYAML(accessObject)
+	if err != nil {
+		t.Fatalf("failed to parse object: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.path.String(), func(t *testing.T) {
+			got, found, err := object.Get(tt.path)
+			if tt.invalid {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get: %v", err)
+			}
+			if found != tt.found {
+				t.Fatalf("expected found to be %v, got %v", tt.found, found)
+			}
+			if !found || tt.expected == nil {
+				return
+			}
+			if !value.Equals(got, _V(tt.expected)) {
+				t.Errorf("expected %v, got %v", tt.expected, value.ToString(got))
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     fieldpath.Path
+		value    interface{}
+		expected typed.YAMLObject
+		invalid  bool
+	}{{
+		name:  "replace field",
+		path:  _P("name"),
+		value: "b",
+		expected: `
+name: b
+labels:
+  a: b
+set: [a, b]
+list:
+- key: a
+  id: 1
+  value: x
+- key: b
+  id: 2
+`,
+	}, {
+		name:  "create nested field",
+		path:  _P("list", _KBF("key", "c", "id", 3), "value"),
+		value: "w",
+		expected: `
+name: a
+labels:
+  a: b
+set: [a, b]
+list:
+- key: a
+  id: 1
+  value: x
+- key: b
+  id: 2
+- key: c
+  id: 3
+  value: w
+`,
+	}, {
+		name:  "update list item",
+		path:  _P("list", _KBF("key", "b", "id", 2), "value"),
+		value: "z",
+		expected: `
+name: a
+labels:
+  a: b
+set: [a, b]
+list:
+- key: a
+  id: 1
+  value: x
+- key: b
+  id: 2
+  value: z
+`,
+	}, {
+		name:  "add set item",
+		path:  _P("set", _V("c")),
+		value: "c",
+		expected: `
+name: a
+labels:
+  a: b
+set: [a, b, c]
+list:
+- key: a
+  id: 1
+  value: x
+- key: b
+  id: 2
+`,
+	}, {
+		name:    "mismatched set item",
+		path:    _P("set", _V("c")),
+		value:   "d",
+		invalid: true,
+	}, {
+		name:    "changing keys",
+		path:    _P("list", _KBF("key", "a", "id", 1), "key"),
+		value:   "b",
+		invalid: true,
+	}, {
+		name:    "wrong type",
+		path:    _P("labels", "c"),
+		value:   1,
+		invalid: true,
+	}, {
+		name:    "unknown field",
+		path:    _P("unknown"),
+		value:   1,
+		invalid: true,
+	}}
+
+	pt := extractParser.Type("type")
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			object, err := pt.FromYAML(accessObject)
+			if err != nil {
+				t.Fatalf("failed to parse object: %v", err)
+			}
+			original, err := pt.FromYAML(accessObject)
+			if err != nil {
+				t.Fatalf("failed to parse object: %v", err)
+			}
+			got, err := object.Set(tt.path, _V(tt.value))
+			if !value.Equals(object.AsValue(), original.AsValue()) {
+				t.Fatalf("original object was modified: %v", value.ToString(object.AsValue()))
+			}
+			if tt.invalid {
+				if err == nil {
+					t.Fatalf("expected error, got %v", value.ToString(got.AsValue()))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected, err := pt.FromYAML(tt.expected)
+			if err != nil {
+				t.Fatalf("failed to parse expected object: %v", err)
+			}
+			if !value.Equals(got.AsValue(), expected.AsValue()) {
+				t.Errorf("expected:\n%v\ngot:\n%v", value.ToString(expected.AsValue()), value.ToString(got.AsValue()))
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     fieldpath.Path
+		expected typed.YAMLObject
+		invalid  bool
+	}{{
+		name: "delete field",
+		path: _P("labels"),
+		expected: `
+name: a
+set: [a, b]
+list:
+- key: a
+  id: 1
+  value: x
+- key: b
+  id: 2
+`,
+	}, {
+		name: "delete list item",
+		path: _P("list", _KBF("key", "a", "id", 1)),
+		expected: `
+name: a
+labels:
+  a: b
+set: [a, b]
+list:
+- key: b
+  id: 2
+`,
+	}, {
+		name: "delete set item",
+		path: _P("set", _V("a")),
+		expected: `
+name: a
+labels:
+  a: b
+set: [b]
+list:
+- key: a
+  id: 1
+  value: x
+- key: b
+  id: 2
+`,
+	}, {
+		name:     "missing path",
+		path:     _P("list", _KBF("key", "c", "id", 3), "value"),
+		expected: accessObject,
+	}, {
+		name:    "delete key field",
+		path:    _P("list", _KBF("key", "a", "id", 1), "key"),
+		invalid: true,
+	}}
+
+	pt := extractParser.Type("type")
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			object, err := pt.FromYAML(accessObject)
+			if err != nil {
+				t.Fatalf("failed to parse object: %v", err)
+			}
+			original, err := pt.FromYAML(accessObject)
+			if err != nil {
+				t.Fatalf("failed to parse object: %v", err)
+			}
+			got, err := object.Delete(tt.path)
+			if !value.Equals(object.AsValue(), original.AsValue()) {
+				t.Fatalf("original object was modified: %v", value.ToString(object.AsValue()))
+			}
+			if tt.invalid {
+				if err == nil {
+					t.Fatalf("expected error, got %v", value.ToString(got.AsValue()))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected, err := pt.FromYAML(tt.expected)
+			if err != nil {
+				t.Fatalf("failed to parse expected object: %v", err)
+			}
+			if !value.Equals(got.AsValue(), expected.AsValue()) {
+				t.Errorf("expected:\n%v\ngot:\n%v", value.ToString(expected.AsValue()), value.ToString(got.AsValue()))
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"math"
+	"sort"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// canonicalize returns the canonical form of v.
+func (a *accessor) canonicalize(v value.Value, tr schema.TypeRef) (value.Value, error) {
+	if v == nil || v.IsNull() {
+		return value.NewValueInterface(nil), nil
+	}
+	atom, err := a.resolve(tr, v)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case atom.Scalar != nil:
+		return canonicalScalar(*atom.Scalar, v), nil
+	case atom.List != nil:
+		return a.canonicalList(atom.List, v)
+	case atom.Map != nil:
+		return a.canonicalMap(atom.Map, v)
+	}
+	return nil, kindErrorf(SchemaError, "schema error: invalid atom")
+}
+
+// canonicalScalar represents every number of a numeric or untyped scalar
+// as an int64 if it can be represented exactly, and as a float64 otherwise.
+func canonicalScalar(s schema.Scalar, v value.Value) value.Value {
+	if s == schema.String || s == schema.Boolean {
+		return value.NewValueInterface(v.Unstructured())
+	}
+	switch {
+	case v.IsInt():
+		return value.NewValueInterface(v.AsInt())
+	case v.IsFloat():
+		f := v.AsFloat()
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return value.NewValueInterface(int64(f))
+		}
+		return value.NewValueInterface(f)
+	}
+	return value.NewValueInterface(v.Unstructured())
+}
+
+// canonicalList canonicalizes every item of the list, and sorts the items
+// of associative lists by key, since their order doesn't matter.
+func (a *accessor) canonicalList(t *schema.List, v value.Value) (value.Value, error) {
+	l, err := listValue(a.allocator, v)
+	if err != nil {
+		return nil, err
+	}
+	defer a.allocator.Free(l)
+	type item struct {
+		pe    fieldpath.PathElement
+		value value.Value
+	}
+	items := make([]item, 0, l.Length())
+	for i := 0; i < l.Length(); i++ {
+		child := l.At(i)
+		out, err := a.canonicalize(child, t.ElementType)
+		if err != nil {
+			return nil, err
+		}
+		var pe fieldpath.PathElement
+		if t.ElementRelationship == schema.Associative {
+			pe, err = listItemToPathElement(a.allocator, a.schema, t, i, child)
+			if err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, item{pe: pe, value: out})
+	}
+	if t.ElementRelationship == schema.Associative {
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].pe.Less(items[j].pe)
+		})
+	}
+	out := make([]value.Value, 0, len(items))
+	for _, item := range items {
+		out = append(out, item.value)
+	}
+	return value.NewValueItems(out), nil
+}
+
+// canonicalMap canonicalizes every field of the map. The fields declared
+// in the schema come first, in the order of the schema, followed by the
+// other fields sorted by name. Null declared fields are dropped unless the
+// map is atomic, since they mean the same as a missing field: other nulls
+// are values of the element type of the map, and are kept.
+func (a *accessor) canonicalMap(t *schema.Map, v value.Value) (value.Value, error) {
+	m, err := mapValue(a.allocator, v)
+	if err != nil {
+		return nil, err
+	}
+	defer a.allocator.Free(m)
+	out := make(value.FieldList, 0, m.Length())
+	for _, sf := range t.Fields {
+		val, ok := m.Get(sf.Name)
+		if !ok || (val.IsNull() && t.ElementRelationship != schema.Atomic) {
+			continue
+		}
+		c, err := a.canonicalize(val, sf.Type)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, value.Field{Name: sf.Name, Value: c})
+	}
+	var others value.FieldList
+	m.Iterate(func(k string, val value.Value) bool {
+		if _, ok := t.FindField(k); ok {
+			return true
+		}
+		var ft schema.TypeRef
+		if ft, err = fieldType(t, k); err != nil {
+			return false
+		}
+		var c value.Value
+		if c, err = a.canonicalize(val, ft); err != nil {
+			return false
+		}
+		others = append(others, value.Field{Name: k, Value: c})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	others.Sort()
+	return value.NewValueFields(append(out, others...)), nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		objects  []typed.YAMLObject
+		expected string
+	}{{
+		name: "numbers",
+		objects: []typed.YAMLObject{
+			`{"replicas": 3}`,
+			`{"replicas": 3.0}`,
+		},
+		expected: `{"replicas":3}`,
+	}, {
+		name: "fractional numbers",
+		objects: []typed.YAMLObject{
+			`{"replicas": 2.5, "list": [{"key": "a", "id": 1.5}]}`,
+		},
+		expected: `{"replicas":2.5,"list":[{"key":"a","id":1.5}]}`,
+	}, {
+		name: "sets",
+		objects: []typed.YAMLObject{
+			`{"set": ["b", "a", "c"]}`,
+			`{"set": ["c", "b", "a"]}`,
+			`{"set": ["a", "b", "c"]}`,
+		},
+		expected: `{"set":["a","b","c"]}`,
+	}, {
+		name: "keyed lists",
+		objects: []typed.YAMLObject{
+			`{"list": [{"key": "b", "id": 2}, {"key": "a", "id": 1, "value": "x"}, {"key": "a", "id": 2}]}`,
+			`{"list": [{"key": "a", "id": 2}, {"key": "b", "id": 2.0}, {"value": "x", "id": 1, "key": "a"}]}`,
+		},
+		expected: `{"list":[{"key":"a","id":1,"value":"x"},{"key":"a","id":2},{"key":"b","id":2}]}`,
+	}, {
+		name: "field order",
+		objects: []typed.YAMLObject{
+			`{"list": [{"value": "x", "id": 1, "key": "a"}], "labels": {"b": "c", "a": "d"}, "name": "a"}`,
+			`{"name": "a", "labels": {"a": "d", "b": "c"}, "list": [{"key": "a", "id": 1, "value": "x"}]}`,
+		},
+		expected: `{"name":"a","labels":{"a":"d","b":"c"},"list":[{"key":"a","id":1,"value":"x"}]}`,
+	}, {
+		name: "nulls",
+		objects: []typed.YAMLObject{
+			`{"name": null, "labels": {"a": null}, "atomic": {"b": null}, "list": [{"key": "a", "id": 1, "value": null}]}`,
+			`{"labels": {"a": null}, "atomic": {"b": null}, "list": [{"key": "a", "id": 1}]}`,
+		},
+		expected: `{"labels":{"a":null},"atomic":{"b":null},"list":[{"key":"a","id":1}]}`,
+	}}
+
+	pt := extractParser.Type("type")
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			for _, object := range tt.objects {
+				tv, err := pt.FromYAML(object)
+				if err != nil {
+					t.Fatalf("failed to parse object: %v", err)
+				}
+				canonical, err := tv.Canonicalize()
+				if err != nil {
+					t.Fatalf("failed to canonicalize %v: %v", object, err)
+				}
+				if err := canonical.Validate(); err != nil {
+					t.Fatalf("canonical form of %v is invalid: %v", object, err)
+				}
+				got, err := value.ToJSON(canonical.AsValue())
+				if err != nil {
+					t.Fatalf("failed to serialize: %v", err)
+				}
+				if string(got) != tt.expected {
+					t.Errorf("expected %v to canonicalize to %v, got %v", object, tt.expected, string(got))
+				}
+				// The unstructured form has the same content.
+				unstructured, err := json.Marshal(canonical.AsValue().Unstructured())
+				if err != nil {
+					t.Fatalf("failed to serialize: %v", err)
+				}
+				if expected, err := value.FromJSON([]byte(tt.expected)); err != nil {
+					t.Fatal(err)
+				} else if actual, err := value.FromJSON(unstructured); err != nil {
+					t.Fatal(err)
+				} else if !value.Equals(expected, actual) {
+					t.Errorf("expected unstructured form %v, got %s", tt.expected, unstructured)
+				}
+			}
+		})
+	}
+}
+
+func TestCanonicalizeYAML(t *testing.T) {
+	tv, err := extractParser.Type("type").FromYAML(`{"replicas": 1.0, "name": "a", "set": ["b", "a"]}`)
+	if err != nil {
+		t.Fatalf("failed to parse object: %v", err)
+	}
+	canonical, err := tv.Canonicalize()
+	if err != nil {
+		t.Fatalf("failed to canonicalize: %v", err)
+	}
+	got, err := value.ToYAML(canonical.AsValue())
+	if err != nil {
+		t.Fatalf("failed to serialize: %v", err)
+	}
+	expected := "name: a\nreplicas: 1\nset:\n- a\n- b\n"
+	if string(got) != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, string(got))
+	}
+}
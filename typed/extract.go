@@ -0,0 +1,174 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+type extractingWalker struct {
+	value     value.Value
+	out       interface{}
+	schema    *schema.Schema
+	toExtract *fieldpath.Set
+	allocator value.Allocator
+}
+
+// extractItemsWithSchema returns the parts of val that are mentioned in
+// toExtract. Fields and items that are members of the set are kept, along
+// with the key fields of every keyed list item that is kept, so that the
+// result can be applied again.
+func extractItemsWithSchema(a value.Allocator, val value.Value, toExtract *fieldpath.Set, schema *schema.Schema, typeRef schema.TypeRef) (value.Value, ValidationErrors) {
+	w := &extractingWalker{
+		value:     val,
+		schema:    schema,
+		toExtract: toExtract,
+		allocator: a,
+	}
+	if errs := resolveSchema(schema, typeRef, val, w); len(errs) != 0 {
+		return nil, errs
+	}
+	return value.NewValueInterface(w.out), nil
+}
+
+// extractChild extracts a child of the current value. The child is kept if
+// it is a member of the set itself, or if any of its descendants are.
+func (w *extractingWalker) extractChild(pe fieldpath.PathElement, child value.Value, tr schema.TypeRef) (interface{}, bool, ValidationErrors) {
+	path, _ := fieldpath.MakePath(pe)
+	isMember := w.toExtract.Has(path)
+	subset := w.toExtract.WithPrefix(pe)
+	if !isMember && subset.Empty() {
+		return nil, false, nil
+	}
+	extracted, errs := extractItemsWithSchema(w.allocator, child, subset, w.schema, tr)
+	if len(errs) != 0 {
+		return nil, false, errs.WithPathElementPrefix(pe)
+	}
+	out := extracted.Unstructured()
+	if !isMember && isEmptyContainer(out) {
+		return nil, false, nil
+	}
+	return out, true, nil
+}
+
+func isEmptyContainer(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(t) == 0
+	case []interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+func (w *extractingWalker) doScalar(t *schema.Scalar) ValidationErrors {
+	w.out = w.value.Unstructured()
+	return nil
+}
+
+func (w *extractingWalker) doList(t *schema.List) (errs ValidationErrors) {
+	if !w.value.IsList() {
+		w.out = w.value.Unstructured()
+		return nil
+	}
+	// Atomic lists are owned as a whole.
+	if t.ElementRelationship == schema.Atomic {
+		w.out = w.value.Unstructured()
+		return nil
+	}
+	l := w.value.AsListUsing(w.allocator)
+	defer w.allocator.Free(l)
+
+	newItems := []interface{}{}
+	iter := l.RangeUsing(w.allocator)
+	defer w.allocator.Free(iter)
+	for iter.Next() {
+		i, item := iter.Item()
+		pe, err := listItemToPathElement(w.allocator, w.schema, t, i, item)
+		if err != nil {
+			errs = append(errs, wrapErrorf(err, "").WithPathElementPrefix(fieldpath.PathElement{Index: &i})...)
+			continue
+		}
+		out, ok, childErrs := w.extractChild(pe, item, t.ElementType)
+		if len(childErrs) != 0 {
+			errs = append(errs, childErrs...)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if pe.Key != nil {
+			out = w.withKeyFields(t, item, out)
+		}
+		newItems = append(newItems, out)
+	}
+	w.out = newItems
+	return errs
+}
+
+// withKeyFields copies the key fields of a keyed list item into its
+// extracted counterpart, so that the extracted item can still be matched
+// against the live list.
+func (w *extractingWalker) withKeyFields(t *schema.List, item value.Value, out interface{}) interface{} {
+	m, ok := out.(map[string]interface{})
+	if !ok || m == nil {
+		m = map[string]interface{}{}
+	}
+	im := item.AsMapUsing(w.allocator)
+	defer w.allocator.Free(im)
+	for _, key := range t.Keys {
+		if v, ok := im.Get(key); ok {
+			m[key] = v.Unstructured()
+		}
+	}
+	return m
+}
+
+func (w *extractingWalker) doMap(t *schema.Map) (errs ValidationErrors) {
+	if !w.value.IsMap() {
+		w.out = w.value.Unstructured()
+		return nil
+	}
+	// Atomic maps are owned as a whole.
+	if t.ElementRelationship == schema.Atomic {
+		w.out = w.value.Unstructured()
+		return nil
+	}
+	m := w.value.AsMapUsing(w.allocator)
+	defer w.allocator.Free(m)
+
+	newMap := map[string]interface{}{}
+	m.Iterate(func(k string, val value.Value) bool {
+		pe := fieldpath.PathElement{FieldName: &k}
+		fieldType := t.ElementType
+		if sf, ok := t.FindField(k); ok {
+			fieldType = sf.Type
+		}
+		out, ok, childErrs := w.extractChild(pe, val, fieldType)
+		errs = append(errs, childErrs...)
+		if ok {
+			newMap[k] = out
+		}
+		return true
+	})
+	w.out = newMap
+	return errs
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+type extractTestCase struct {
+	name     string
+	object   typed.YAMLObject
+	set      *fieldpath.Set
+	expected typed.YAMLObject
+}
+
+var extractParser = func() *typed.Parser {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: replicas
+      type:
+        scalar: numeric
+    - name: labels
+      type:
+        map:
+          elementType:
+            scalar: string
+    - name: atomic
+      type:
+        map:
+          elementType:
+            scalar: string
+          elementRelationship: atomic
+    - name: set
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: associative
+    - name: list
+      type:
+        list:
+          elementType:
+            namedType: item
+          elementRelationship: associative
+          keys:
+          - key
+          - id
+- name: item
+  map:
+    fields:
+    - name: key
+      type:
+        scalar: string
+    - name: id
+      type:
+        scalar: numeric
+    - name: value
+      type:
+        scalar: string
+    - name: other
+      type:
+        scalar: string
+`)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}()
+
+var extractCases = []extractTestCase{{
+	name: "empty set",
+	object: `
+name: a
+replicas: 1
+`,
+	set:      _NS(),
+	expected: `{}`,
+}, {
+	name: "scalar fields",
+	object: `
+name: a
+replicas: 1
+`,
+	set:      _NS(_P("replicas")),
+	expected: `{"replicas": 1}`,
+}, {
+	name: "map items",
+	object: `
+labels:
+  a: b
+  c: d
+`,
+	set:      _NS(_P("labels", "c")),
+	expected: `{"labels": {"c": "d"}}`,
+}, {
+	name: "owned empty map",
+	object: `
+labels:
+  a: b
+`,
+	set:      _NS(_P("labels")),
+	expected: `{"labels": {}}`,
+}, {
+	name: "atomic map",
+	object: `
+atomic:
+  a: b
+  c: d
+`,
+	set:      _NS(_P("atomic")),
+	expected: `{"atomic": {"a": "b", "c": "d"}}`,
+}, {
+	name: "set items",
+	object: `
+set: [a, b, c]
+`,
+	set:      _NS(_P("set", _V("a")), _P("set", _V("c"))),
+	expected: `{"set": [a, c]}`,
+}, {
+	name: "list items keep their keys",
+	object: `
+list:
+- key: a
+  id: 1
+  value: x
+  other: w
+- key: b
+  id: 2
+  value: z
+`,
+	set:      _NS(_P("list", _KBF("key", "a", "id", 1), "value")),
+	expected: `{"list": [{"key": "a", "id": 1, "value": "x"}]}`,
+}, {
+	name: "owned list item without fields",
+	object: `
+list:
+- key: a
+  id: 1
+  value: x
+- key: b
+  id: 2
+  value: z
+`,
+	set:      _NS(_P("list", _KBF("key", "b", "id", 2))),
+	expected: `{"list": [{"key": "b", "id": 2}]}`,
+}, {
+	name: "missing fields are ignored",
+	object: `
+name: a
+`,
+	set:      _NS(_P("replicas"), _P("labels", "a")),
+	expected: `{}`,
+}}
+
+func TestExtractItems(t *testing.T) {
+	for _, tt := range extractCases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pt := extractParser.Type("type")
+			object, err := pt.FromYAML(tt.object)
+			if err != nil {
+				t.Fatalf("failed to parse object: %v", err)
+			}
+			expected, err := pt.FromYAML(tt.expected)
+			if err != nil {
+				t.Fatalf("failed to parse expected object: %v", err)
+			}
+			got, err := object.ExtractItems(tt.set)
+			if err != nil {
+				t.Fatalf("failed to extract: %v", err)
+			}
+			if err := got.Validate(); err != nil {
+				t.Fatalf("extracted object is invalid: %v", err)
+			}
+			comparison, err := got.Compare(expected)
+			if err != nil {
+				t.Fatalf("failed to compare: %v", err)
+			}
+			if !comparison.IsSame() {
+				t.Errorf("unexpected extracted object:\n%v", comparison)
+			}
+		})
+	}
+}
+
+func TestExtractItemsInvalid(t *testing.T) {
+	pt := extractParser.Type("type")
+	// The items of the list can't be identified without their keys.
+	object := typed.AsTypedUnvalidated(value.NewValueInterface(map[string]interface{}{
+		"list": []interface{}{map[string]interface{}{"value": "a"}},
+	}), pt.Schema, pt.TypeRef)
+	if _, err := object.ExtractItems(_NS(_P("list"))); err == nil {
+		t.Fatal("expected an error for a list item without keys")
+	}
+}
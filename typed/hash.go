@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math"
+	"sort"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// Hash returns a hex-encoded SHA-256 digest of the canonical form of the
+// value (see Canonicalize). Values that only differ in the order of their
+// fields or of the items of associative lists, in the encoding of their
+// numbers, or in null declared fields of non-atomic maps have the same hash.
+func (tv TypedValue) Hash() (string, error) {
+	c, err := tv.Canonicalize()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if err := writeHash(h, c.value.Unstructured()); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashItems returns the hash of the part of the value described by items,
+// as extracted by ExtractItems.
+func (tv TypedValue) HashItems(items *fieldpath.Set) (string, error) {
+	extracted, err := tv.ExtractItems(items)
+	if err != nil {
+		return "", err
+	}
+	return extracted.Hash()
+}
+
+// writeHash writes a canonical value to h. Every value starts with a tag
+// for its type, and strings and containers with their length, so that no
+// two different values are written the same way.
+func writeHash(h hash.Hash, v interface{}) error {
+	var buf [9]byte
+	switch v := v.(type) {
+	case nil:
+		h.Write([]byte{'n'})
+	case bool:
+		buf[0] = 'b'
+		if v {
+			buf[1] = 1
+		}
+		h.Write(buf[:2])
+	case int64:
+		buf[0] = 'i'
+		binary.BigEndian.PutUint64(buf[1:], uint64(v))
+		h.Write(buf[:])
+	case float64:
+		buf[0] = 'f'
+		binary.BigEndian.PutUint64(buf[1:], math.Float64bits(v))
+		h.Write(buf[:])
+	case string:
+		writeHashString(h, 's', v)
+	case []interface{}:
+		buf[0] = 'l'
+		binary.BigEndian.PutUint64(buf[1:], uint64(len(v)))
+		h.Write(buf[:])
+		for _, item := range v {
+			if err := writeHash(h, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		buf[0] = 'm'
+		binary.BigEndian.PutUint64(buf[1:], uint64(len(v)))
+		h.Write(buf[:])
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			writeHashString(h, 'k', k)
+			if err := writeHash(h, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("can't hash value of type %T", v)
+	}
+	return nil
+}
+
+func writeHashString(h hash.Hash, tag byte, s string) {
+	var buf [9]byte
+	buf[0] = tag
+	binary.BigEndian.PutUint64(buf[1:], uint64(len(s)))
+	h.Write(buf[:])
+	h.Write([]byte(s))
+}
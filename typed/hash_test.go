@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		name  string
+		lhs   typed.YAMLObject
+		rhs   typed.YAMLObject
+		items *fieldpath.Set
+		same  bool
+	}{{
+		name: "field order",
+		lhs:  `{"name": "a", "replicas": 1}`,
+		rhs:  `{"replicas": 1, "name": "a"}`,
+		same: true,
+	}, {
+		name: "set order",
+		lhs:  `{"set": ["a", "b"]}`,
+		rhs:  `{"set": ["b", "a"]}`,
+		same: true,
+	}, {
+		name: "list order",
+		lhs:  `{"list": [{"key": "a", "id": 1}, {"key": "b", "id": 2}]}`,
+		rhs:  `{"list": [{"key": "b", "id": 2}, {"key": "a", "id": 1}]}`,
+		same: true,
+	}, {
+		name: "number encoding",
+		lhs:  `{"replicas": 1}`,
+		rhs:  `{"replicas": 1.0}`,
+		same: true,
+	}, {
+		name: "different values",
+		lhs:  `{"replicas": 1}`,
+		rhs:  `{"replicas": 2}`,
+		same: false,
+	}, {
+		name: "null fields",
+		lhs:  `{"labels": {"a": "1"}}`,
+		rhs:  `{"labels": {"a": "1"}, "name": null}`,
+		same: true,
+	}, {
+		name: "null values",
+		lhs:  `{"labels": {"a": "1"}}`,
+		rhs:  `{"labels": {"a": "1", "b": null}}`,
+		same: false,
+	}, {
+		name: "moved value",
+		lhs:  `{"labels": {"a": "b"}}`,
+		rhs:  `{"labels": {"ab": ""}}`,
+		same: false,
+	}, {
+		name:  "subset unchanged",
+		lhs:   `{"name": "a", "replicas": 1, "set": ["a"]}`,
+		rhs:   `{"name": "b", "replicas": 1.0, "set": ["a", "b"]}`,
+		items: _NS(_P("replicas"), _P("set", _V("a"))),
+		same:  true,
+	}, {
+		name:  "subset changed",
+		lhs:   `{"name": "a", "replicas": 1}`,
+		rhs:   `{"name": "b", "replicas": 1}`,
+		items: _NS(_P("name")),
+		same:  false,
+	}}
+
+	pt := extractParser.Type("type")
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			hash := func(object typed.YAMLObject) string {
+				tv, err := pt.FromYAML(object)
+				if err != nil {
+					t.Fatalf("failed to parse object: %v", err)
+				}
+				var h string
+				if tt.items != nil {
+					h, err = tv.HashItems(tt.items)
+				} else {
+					h, err = tv.Hash()
+				}
+				if err != nil {
+					t.Fatalf("failed to hash %v: %v", object, err)
+				}
+				return h
+			}
+			lhs, rhs := hash(tt.lhs), hash(tt.rhs)
+			if (lhs == rhs) != tt.same {
+				t.Errorf("expected same hash to be %v, got %v and %v", tt.same, lhs, rhs)
+			}
+		})
+	}
+}
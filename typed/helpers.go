@@ -17,7 +17,7 @@ limitations under the License.
 package typed
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -26,10 +26,48 @@ import (
 	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
 
+// ErrorKind classifies validation errors so that they can be handled
+// programmatically.
+type ErrorKind string
+
+const (
+	// TypeMismatchError means that a value doesn't have the type its
+	// schema requires.
+	TypeMismatchError = ErrorKind("typeMismatch")
+	// DuplicateKeyError means that two items of an associative list
+	// have the same key.
+	DuplicateKeyError = ErrorKind("duplicateKey")
+	// MissingKeyError means that an item of an associative list omits
+	// one of its key fields.
+	MissingKeyError = ErrorKind("missingKey")
+	// UnknownFieldError means that a field isn't declared in the schema.
+	UnknownFieldError = ErrorKind("unknownField")
+	// SchemaError means that the schema itself is invalid or incomplete.
+	SchemaError = ErrorKind("schemaError")
+	// UnionViolationError means that the rules of a union are violated.
+	UnionViolationError = ErrorKind("unionViolation")
+	// InvalidError is used for any other error.
+	InvalidError = ErrorKind("invalid")
+)
+
 // ValidationError reports an error about a particular field
 type ValidationError struct {
-	Path         string
+	// Path is the path of the field the error is about, as text.
+	Path string
+	// FieldPath is the path of the field the error is about, relative to
+	// the root of the object. It is empty if the path is only known as
+	// text, as set by the deprecated string-based helpers, in which case
+	// it can be the end of Path.
+	FieldPath fieldpath.Path
+	// Kind classifies the error.
+	Kind ErrorKind
+	// ErrorMessage is a human readable description of the error.
 	ErrorMessage string
+	// ExpectedType and ActualType are set for type mismatches. They are
+	// one of "numeric", "string", "boolean", "scalar", "list", "map" or
+	// "null".
+	ExpectedType string
+	ActualType   string
 }
 
 // Error returns a human readable error message.
@@ -40,6 +78,85 @@ func (ve ValidationError) Error() string {
 	return fmt.Sprintf("%s: %v", ve.Path, ve.ErrorMessage)
 }
 
+// textPrefix returns the part of Path that comes before FieldPath, which
+// is only known as text.
+func (ve ValidationError) textPrefix() string {
+	if len(ve.FieldPath) == 0 {
+		return ve.Path
+	}
+	return strings.TrimSuffix(ve.Path, ve.FieldPath.String())
+}
+
+// setFieldPath sets the path of the error, after a textual prefix.
+func (ve *ValidationError) setFieldPath(prefix string, p fieldpath.Path) {
+	ve.FieldPath = p
+	if len(p) == 0 {
+		ve.Path = prefix
+	} else {
+		ve.Path = prefix + p.String()
+	}
+}
+
+// validationErrorJSON is the serialized form of a ValidationError. The
+// elements of FieldPath use the same encoding as the keys of serialized
+// fieldpath.Sets.
+type validationErrorJSON struct {
+	Field        string    `json:"field,omitempty"`
+	Path         []string  `json:"path,omitempty"`
+	Kind         ErrorKind `json:"kind"`
+	Message      string    `json:"message"`
+	ExpectedType string    `json:"expectedType,omitempty"`
+	ActualType   string    `json:"actualType,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (ve ValidationError) MarshalJSON() ([]byte, error) {
+	out := validationErrorJSON{
+		Kind:         ve.Kind,
+		Message:      ve.ErrorMessage,
+		ExpectedType: ve.ExpectedType,
+		ActualType:   ve.ActualType,
+	}
+	out.Field = ve.Path
+	for _, pe := range ve.FieldPath {
+		s, err := fieldpath.SerializePathElement(pe)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize path element %v: %v", pe, err)
+		}
+		out.Path = append(out.Path, s)
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (ve *ValidationError) UnmarshalJSON(data []byte) error {
+	var in validationErrorJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*ve = ValidationError{
+		Kind:         in.Kind,
+		ErrorMessage: in.Message,
+		ExpectedType: in.ExpectedType,
+		ActualType:   in.ActualType,
+	}
+	var p fieldpath.Path
+	for _, s := range in.Path {
+		pe, err := fieldpath.DeserializePathElement(s)
+		if err != nil {
+			return fmt.Errorf("failed to deserialize path element %q: %v", s, err)
+		}
+		p = append(p, pe)
+	}
+	// Whatever comes before FieldPath was set as text.
+	prefix := in.Field
+	if len(p) != 0 {
+		prefix = strings.TrimSuffix(in.Field, p.String())
+	}
+	ve.setFieldPath(prefix, p)
+	return nil
+}
+
 // ValidationErrors accumulates multiple validation error messages.
 type ValidationErrors []ValidationError
 
@@ -56,19 +173,52 @@ func (errs ValidationErrors) Error() string {
 	return strings.Join(messages, "\n")
 }
 
+// WithFieldPath sets the given path to all the validation errors.
+func (errs ValidationErrors) WithFieldPath(p fieldpath.Path) ValidationErrors {
+	for i := range errs {
+		errs[i].setFieldPath("", p.Copy())
+	}
+	return errs
+}
+
+// WithPathElementPrefix prefixes all errors path with the given path
+// element. This is useful when unwinding the stack on errors.
+func (errs ValidationErrors) WithPathElementPrefix(pe fieldpath.PathElement) ValidationErrors {
+	if len(errs) == 0 {
+		return errs
+	}
+	// The path element may reference values that are freed once the
+	// stack is unwound, so keep a copy.
+	pe = copyPathElement(pe)
+	for i := range errs {
+		if prefix := errs[i].textPrefix(); prefix != "" {
+			// FieldPath comes after the textual prefix.
+			errs[i].setFieldPath(pe.String()+prefix, errs[i].FieldPath)
+			continue
+		}
+		errs[i].setFieldPath("", append(fieldpath.Path{pe}, errs[i].FieldPath...))
+	}
+	return errs
+}
+
 // Set the given path to all the validation errors.
+//
+// Deprecated: the path is only kept as text, use WithFieldPath instead.
 func (errs ValidationErrors) WithPath(p string) ValidationErrors {
 	for i := range errs {
-		errs[i].Path = p
+		errs[i].setFieldPath(p, nil)
 	}
 	return errs
 }
 
 // WithPrefix prefixes all errors path with the given pathelement. This
 // is useful when unwinding the stack on errors.
+//
+// Deprecated: the prefix is only kept as text, use WithPathElementPrefix
+// instead.
 func (errs ValidationErrors) WithPrefix(prefix string) ValidationErrors {
 	for i := range errs {
-		errs[i].Path = prefix + errs[i].Path
+		errs[i].setFieldPath(prefix+errs[i].textPrefix(), errs[i].FieldPath)
 	}
 	return errs
 }
@@ -76,6 +226,9 @@ func (errs ValidationErrors) WithPrefix(prefix string) ValidationErrors {
 // WithLazyPrefix prefixes all errors path with the given pathelement.
 // This is useful when unwinding the stack on errors. Prefix is
 // computed lazily only if there is an error.
+//
+// Deprecated: the prefix is only kept as text, use WithPathElementPrefix
+// instead.
 func (errs ValidationErrors) WithLazyPrefix(fn func() string) ValidationErrors {
 	if len(errs) == 0 {
 		return errs
@@ -84,16 +237,90 @@ func (errs ValidationErrors) WithLazyPrefix(fn func() string) ValidationErrors {
 	if fn != nil {
 		prefix = fn()
 	}
-	for i := range errs {
-		errs[i].Path = prefix + errs[i].Path
+	return errs.WithPrefix(prefix)
+}
+
+func copyPathElement(pe fieldpath.PathElement) fieldpath.PathElement {
+	out := fieldpath.PathElement{
+		FieldName: pe.FieldName,
+		Index:     pe.Index,
 	}
-	return errs
+	if pe.Key != nil {
+		key := make(value.FieldList, 0, len(*pe.Key))
+		for _, f := range *pe.Key {
+			key = append(key, value.Field{Name: f.Name, Value: value.NewValueInterface(f.Value.Unstructured())})
+		}
+		out.Key = &key
+	}
+	if pe.Value != nil {
+		v := value.NewValueInterface((*pe.Value).Unstructured())
+		out.Value = &v
+	}
+	return out
 }
 
 func errorf(format string, args ...interface{}) ValidationErrors {
-	return ValidationErrors{{
+	return kindErrorf(InvalidError, format, args...)
+}
+
+func kindErrorf(kind ErrorKind, format string, args ...interface{}) ValidationErrors {
+	return ValidationErrors{kindError(kind, format, args...)}
+}
+
+func kindError(kind ErrorKind, format string, args ...interface{}) ValidationError {
+	return ValidationError{
+		Kind:         kind,
 		ErrorMessage: fmt.Sprintf(format, args...),
-	}}
+	}
+}
+
+// typeMismatchError builds an error reporting that actual was found where
+// a value of type expected was required.
+func typeMismatchError(expected string, actual value.Value, format string, args ...interface{}) ValidationError {
+	return ValidationError{
+		Kind:         TypeMismatchError,
+		ErrorMessage: fmt.Sprintf(format, args...),
+		ExpectedType: expected,
+		ActualType:   valueType(actual),
+	}
+}
+
+// valueType returns the name of the type of v, as used in ValidationErrors.
+func valueType(v value.Value) string {
+	switch {
+	case v == nil, v.IsNull():
+		return "null"
+	case v.IsMap():
+		return "map"
+	case v.IsList():
+		return "list"
+	case v.IsString():
+		return "string"
+	case v.IsBool():
+		return "boolean"
+	case v.IsInt(), v.IsFloat():
+		return "numeric"
+	}
+	return ""
+}
+
+// wrapErrorf turns err into ValidationErrors, keeping its kind and types if
+// it is already a validation error, and prefixing its message.
+func wrapErrorf(err error, format string, args ...interface{}) ValidationErrors {
+	prefix := fmt.Sprintf(format, args...)
+	var errs ValidationErrors
+	switch t := err.(type) {
+	case ValidationError:
+		errs = ValidationErrors{t}
+	case ValidationErrors:
+		errs = append(ValidationErrors{}, t...)
+	default:
+		errs = errorf("%v", err)
+	}
+	for i := range errs {
+		errs[i].ErrorMessage = prefix + errs[i].ErrorMessage
+	}
+	return errs
 }
 
 type atomHandler interface {
@@ -105,7 +332,7 @@ type atomHandler interface {
 func resolveSchema(s *schema.Schema, tr schema.TypeRef, v value.Value, ah atomHandler) ValidationErrors {
 	a, ok := s.Resolve(tr)
 	if !ok {
-		return errorf("schema error: no type found matching: %v", *tr.NamedType)
+		return kindErrorf(SchemaError, "schema error: no type found matching: %v", *tr.NamedType)
 	}
 
 	a = deduceAtom(a, v)
@@ -150,7 +377,7 @@ func handleAtom(a schema.Atom, tr schema.TypeRef, ah atomHandler) ValidationErro
 		name = "named type: " + *tr.NamedType
 	}
 
-	return errorf("schema error: invalid atom: %v", name)
+	return kindErrorf(SchemaError, "schema error: invalid atom: %v", name)
 }
 
 // Returns the list, or an error. Reminder: nil is a valid list and might be returned.
@@ -160,7 +387,7 @@ func listValue(a value.Allocator, val value.Value) (value.List, error) {
 		return nil, nil
 	}
 	if !val.IsList() {
-		return nil, fmt.Errorf("expected list, got %v", val)
+		return nil, typeMismatchError("list", val, "expected list, got %v", val)
 	}
 	return val.AsListUsing(a), nil
 }
@@ -168,14 +395,14 @@ func listValue(a value.Allocator, val value.Value) (value.List, error) {
 // Returns the map, or an error. Reminder: nil is a valid map and might be returned.
 func mapValue(a value.Allocator, val value.Value) (value.Map, error) {
 	if val == nil {
-		return nil, fmt.Errorf("expected map, got nil")
+		return nil, typeMismatchError("map", val, "expected map, got nil")
 	}
 	if val.IsNull() {
 		// Null is a valid map.
 		return nil, nil
 	}
 	if !val.IsMap() {
-		return nil, fmt.Errorf("expected map, got %v", val)
+		return nil, typeMismatchError("map", val, "expected map, got %v", val)
 	}
 	return val.AsMapUsing(a), nil
 }
@@ -183,10 +410,10 @@ func mapValue(a value.Allocator, val value.Value) (value.Map, error) {
 func getAssociativeKeyDefault(s *schema.Schema, list *schema.List, fieldName string) (interface{}, error) {
 	atom, ok := s.Resolve(list.ElementType)
 	if !ok {
-		return nil, errors.New("invalid elementType for list")
+		return nil, kindError(SchemaError, "invalid elementType for list")
 	}
 	if atom.Map == nil {
-		return nil, errors.New("associative list may not have non-map types")
+		return nil, kindError(SchemaError, "associative list may not have non-map types")
 	}
 	// If the field is not found, we can assume there is no default.
 	field, _ := atom.Map.FindField(fieldName)
@@ -197,10 +424,10 @@ func keyedAssociativeListItemToPathElement(a value.Allocator, s *schema.Schema,
 	pe := fieldpath.PathElement{}
 	if child.IsNull() {
 		// null entries are illegal.
-		return pe, errors.New("associative list with keys may not have a null element")
+		return pe, typeMismatchError("map", child, "associative list with keys may not have a null element")
 	}
 	if !child.IsMap() {
-		return pe, errors.New("associative list with keys may not have non-map elements")
+		return pe, typeMismatchError("map", child, "associative list with keys may not have non-map elements")
 	}
 	keyMap := value.FieldList{}
 	m := child.AsMapUsing(a)
@@ -209,11 +436,11 @@ func keyedAssociativeListItemToPathElement(a value.Allocator, s *schema.Schema,
 		if val, ok := m.Get(fieldName); ok {
 			keyMap = append(keyMap, value.Field{Name: fieldName, Value: val})
 		} else if def, err := getAssociativeKeyDefault(s, list, fieldName); err != nil {
-			return pe, fmt.Errorf("couldn't find default value for %v: %v", fieldName, err)
+			return pe, wrapErrorf(err, "couldn't find default value for %v: ", fieldName)[0]
 		} else if def != nil {
 			keyMap = append(keyMap, value.Field{Name: fieldName, Value: value.NewValueInterface(def)})
 		} else {
-			return pe, fmt.Errorf("associative list with keys has an element that omits key field %q (and doesn't have default value)", fieldName)
+			return pe, kindError(MissingKeyError, "associative list with keys has an element that omits key field %q (and doesn't have default value)", fieldName)
 		}
 	}
 	keyMap.Sort()
@@ -226,14 +453,14 @@ func setItemToPathElement(list *schema.List, index int, child value.Value) (fiel
 	switch {
 	case child.IsMap():
 		// TODO: atomic maps should be acceptable.
-		return pe, errors.New("associative list without keys has an element that's a map type")
+		return pe, typeMismatchError("scalar", child, "associative list without keys has an element that's a map type")
 	case child.IsList():
 		// Should we support a set of lists? For the moment
 		// let's say we don't.
 		// TODO: atomic lists should be acceptable.
-		return pe, errors.New("not supported: associative list with lists as elements")
+		return pe, typeMismatchError("scalar", child, "not supported: associative list with lists as elements")
 	case child.IsNull():
-		return pe, errors.New("associative list without keys has an element that's an explicit null")
+		return pe, typeMismatchError("scalar", child, "associative list without keys has an element that's an explicit null")
 	default:
 		// We are a set type.
 		pe.Value = &child
@@ -70,14 +70,14 @@ var (
 )
 
 // merge sets w.out.
-func (w *mergingWalker) merge(prefixFn func() string) (errs ValidationErrors) {
+func (w *mergingWalker) merge(pe *fieldpath.PathElement) (errs ValidationErrors) {
 	if w.lhs == nil && w.rhs == nil {
 		// check this condidition here instead of everywhere below.
 		return errorf("at least one of lhs and rhs must be provided")
 	}
 	a, ok := w.schema.Resolve(w.typeRef)
 	if !ok {
-		return errorf("schema error: no type found matching: %v", *w.typeRef.NamedType)
+		return kindErrorf(SchemaError, "schema error: no type found matching: %v", *w.typeRef.NamedType)
 	}
 
 	alhs := deduceAtom(a, w.lhs)
@@ -93,7 +93,10 @@ func (w *mergingWalker) merge(prefixFn func() string) (errs ValidationErrors) {
 	if !w.inLeaf && w.postItemHook != nil {
 		w.postItemHook(w)
 	}
-	return errs.WithLazyPrefix(prefixFn)
+	if pe != nil {
+		errs = errs.WithPathElementPrefix(*pe)
+	}
+	return errs
 }
 
 // doLeaf should be called on leaves before descending into children, if there
@@ -156,7 +159,7 @@ func (w *mergingWalker) derefMap(prefix string, v value.Value) (value.Map, Valid
 	}
 	m, err := mapValue(w.allocator, v)
 	if err != nil {
-		return nil, errorf("%v: %v", prefix, err)
+		return nil, wrapErrorf(err, "%v: ", prefix)
 	}
 	return m, nil
 }
@@ -185,14 +188,14 @@ func (w *mergingWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 			child := rhs.At(i)
 			pe, err := listItemToPathElement(w.allocator, w.schema, t, i, child)
 			if err != nil {
-				errs = append(errs, errorf("rhs: element %v: %v", i, err.Error())...)
+				errs = append(errs, wrapErrorf(err, "rhs: element %v: ", i)...)
 				// If we can't construct the path element, we can't
 				// even report errors deeper in the schema, so bail on
 				// this element.
 				continue
 			}
 			if _, ok := observedRHS.Get(pe); ok {
-				errs = append(errs, errorf("rhs: duplicate entries for key %v", pe.String())...)
+				errs = append(errs, kindErrorf(DuplicateKeyError, "rhs: duplicate entries for key %v", pe.String())...)
 			}
 			observedRHS.Insert(pe, child)
 			rhsOrder = append(rhsOrder, pe)
@@ -206,14 +209,14 @@ func (w *mergingWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 			child := lhs.At(i)
 			pe, err := listItemToPathElement(w.allocator, w.schema, t, i, child)
 			if err != nil {
-				errs = append(errs, errorf("lhs: element %v: %v", i, err.Error())...)
+				errs = append(errs, wrapErrorf(err, "lhs: element %v: ", i)...)
 				// If we can't construct the path element, we can't
 				// even report errors deeper in the schema, so bail on
 				// this element.
 				continue
 			}
 			if observedLHS.Has(pe) {
-				errs = append(errs, errorf("lhs: duplicate entries for key %v", pe.String())...)
+				errs = append(errs, kindErrorf(DuplicateKeyError, "lhs: duplicate entries for key %v", pe.String())...)
 				continue
 			}
 			observedLHS.Insert(pe)
@@ -222,7 +225,7 @@ func (w *mergingWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 			if rchild, ok := observedRHS.Get(pe); ok {
 				w2.rhs = rchild
 			}
-			errs = append(errs, w2.merge(pe.String)...)
+			errs = append(errs, w2.merge(&pe)...)
 			if w2.out != nil {
 				out = append(out, *w2.out)
 			}
@@ -237,7 +240,7 @@ func (w *mergingWalker) visitListItems(t *schema.List, lhs, rhs value.List) (err
 		value, _ := observedRHS.Get(pe)
 		w2 := w.prepareDescent(pe, t.ElementType)
 		w2.rhs = value
-		errs = append(errs, w2.merge(pe.String)...)
+		errs = append(errs, w2.merge(&pe)...)
 		if w2.out != nil {
 			out = append(out, *w2.out)
 		}
@@ -258,7 +261,7 @@ func (w *mergingWalker) derefList(prefix string, v value.Value) (value.List, Val
 	}
 	l, err := listValue(w.allocator, v)
 	if err != nil {
-		return nil, errorf("%v: %v", prefix, err)
+		return nil, wrapErrorf(err, "%v: ", prefix)
 	}
 	return l, nil
 }
@@ -301,7 +304,7 @@ func (w *mergingWalker) visitMapItem(t *schema.Map, out map[string]interface{},
 	w2 := w.prepareDescent(pe, fieldType)
 	w2.lhs = lhs
 	w2.rhs = rhs
-	errs = append(errs, w2.merge(pe.String)...)
+	errs = append(errs, w2.merge(&pe)...)
 	if w2.out != nil {
 		out[key] = *w2.out
 	}
@@ -155,7 +155,7 @@ func ReconcileFieldSetWithSchema(fieldset *fieldpath.Set, tv *TypedValue) (*fiel
 func (v *reconcileWithSchemaWalker) reconcile() (errs ValidationErrors) {
 	a, ok := v.schema.Resolve(v.typeRef)
 	if !ok {
-		errs = append(errs, errorf("could not resolve %v", v.typeRef)...)
+		errs = append(errs, kindErrorf(SchemaError, "could not resolve %v", v.typeRef)...)
 		return
 	}
 	return handleAtom(a, v.typeRef, v)
@@ -256,7 +256,7 @@ func buildGranularFieldSet(path fieldpath.Path, value *TypedValue) (*fieldpath.S
 
 	valueFieldSet, err := value.ToFieldSet()
 	if err != nil {
-		return nil, errorf("toFieldSet: %v", err)
+		return nil, wrapErrorf(err, "toFieldSet: ")
 	}
 	if valueFieldSetAtPath, ok := fieldSetAtPath(valueFieldSet, path); ok {
 		result := fieldpath.NewSet(path)
@@ -0,0 +1,212 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// RedactedValue replaces the string values of sensitive fields.
+const RedactedValue = "<redacted>"
+
+// redact returns a copy of tv where the values of sensitive fields have
+// been replaced. The sensitive fields are found by walking tv, and their
+// subtrees aren't visited.
+func (tv TypedValue) redact() (value.Value, error) {
+	type replacement struct {
+		path fieldpath.Path
+		tr   schema.TypeRef
+		val  value.Value
+	}
+	var replacements []replacement
+	var sensitive fieldpath.Set
+	err := tv.Walk(func(path fieldpath.Path, atom schema.Atom, v value.Value) bool {
+		if sensitive.Has(path) {
+			return false
+		}
+		if atom.Map == nil || v == nil || v.IsNull() || !v.IsMap() {
+			return true
+		}
+		keys := map[string]bool{}
+		if len(path) > 0 && path[len(path)-1].Key != nil {
+			for _, f := range *path[len(path)-1].Key {
+				keys[f.Name] = true
+			}
+		}
+		v.AsMap().Iterate(func(k string, val value.Value) bool {
+			if sf, ok := atom.Map.FindField(k); ok && sf.Sensitive && !keys[k] {
+				p := childPath(path, fieldpath.PathElement{FieldName: &sf.Name})
+				sensitive.Insert(p)
+				replacements = append(replacements, replacement{path: p, tr: sf.Type, val: value.NewValueInterface(val.Unstructured())})
+			}
+			return true
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	a := tv.accessor()
+	out := tv.value
+	for _, r := range replacements {
+		p, err := a.placeholder(r.val, r.tr)
+		if err != nil {
+			return nil, wrapErrorf(err, "").WithFieldPath(r.path)
+		}
+		redacted, err := a.set(out, tv.typeRef, r.path, value.NewValueInterface(p))
+		if err != nil {
+			return nil, wrapErrorf(err, "").WithFieldPath(r.path)
+		}
+		out = value.NewValueInterface(redacted)
+	}
+	return out, nil
+}
+
+// placeholder returns a valid value of the same type as v that doesn't
+// tell anything about v: RedactedValue for strings, zero for numbers, false
+// for booleans and empty lists or maps for containers.
+func (a *accessor) placeholder(v value.Value, tr schema.TypeRef) (interface{}, error) {
+	if v == nil || v.IsNull() {
+		return nil, nil
+	}
+	atom, err := a.resolve(tr, v)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case atom.List != nil:
+		return []interface{}{}, nil
+	case atom.Map != nil:
+		return map[string]interface{}{}, nil
+	case v.IsFloat(), v.IsInt():
+		return int64(0), nil
+	case v.IsBool():
+		return false, nil
+	}
+	return RedactedValue, nil
+}
+
+// PathRedactor redacts the keys and set items found under sensitive fields
+// in paths, so that paths can be shown where sensitive values must not be.
+// Each distinct item of a redacted list is given its own numbered
+// placeholder, so that redacted items stay distinct, and the same item is
+// given the same placeholder in every path of the redactor.
+type PathRedactor struct {
+	schema  *schema.Schema
+	typeRef schema.TypeRef
+
+	// placeholders maps redacted items, by path, to their placeholder.
+	placeholders map[string]string
+	// counts is the number of placeholders given under each redacted
+	// list, by path.
+	counts map[string]int
+}
+
+// NewPathRedactor returns a PathRedactor for the paths of values of the
+// given type.
+func NewPathRedactor(s *schema.Schema, tr schema.TypeRef) *PathRedactor {
+	return &PathRedactor{
+		schema:       s,
+		typeRef:      tr,
+		placeholders: map[string]string{},
+		counts:       map[string]int{},
+	}
+}
+
+// placeholder returns the placeholder of the item pe of the list at path,
+// whose path has already been redacted.
+func (r *PathRedactor) placeholder(path fieldpath.Path, pe fieldpath.PathElement) value.Value {
+	list := path.String()
+	item := list + pe.String()
+	p, ok := r.placeholders[item]
+	if !ok {
+		r.counts[list]++
+		p = fmt.Sprintf("<redacted-%d>", r.counts[list])
+		r.placeholders[item] = p
+	}
+	return value.NewValueInterface(p)
+}
+
+// RedactPath returns the path with the keys and set items found under
+// sensitive fields replaced by numbered placeholders. Once the path
+// doesn't match the schema, the types of the remaining elements are
+// unknown, and only the sensitivity found so far is applied to them.
+func (r *PathRedactor) RedactPath(path fieldpath.Path) fieldpath.Path {
+	out := make(fieldpath.Path, 0, len(path))
+	tr := r.typeRef
+	known := true
+	sensitive := false
+	for _, pe := range path {
+		var atom schema.Atom
+		if known {
+			atom, known = r.schema.Resolve(tr)
+		}
+		switch {
+		case pe.FieldName != nil:
+			if !known || atom.Map == nil {
+				known = false
+				break
+			}
+			if sf, ok := atom.Map.FindField(*pe.FieldName); ok {
+				sensitive = sensitive || sf.Sensitive
+				tr = sf.Type
+			} else {
+				tr = atom.Map.ElementType
+			}
+		default:
+			if known && atom.List == nil {
+				known = false
+			}
+			if known {
+				tr = atom.List.ElementType
+			}
+			if !sensitive {
+				break
+			}
+			switch {
+			case pe.Value != nil:
+				pe = fieldpath.PathElement{Value: valuePtr(r.placeholder(out, pe))}
+			case pe.Key != nil:
+				p := r.placeholder(out, pe)
+				keys := make(value.FieldList, 0, len(*pe.Key))
+				for _, f := range *pe.Key {
+					keys = append(keys, value.Field{Name: f.Name, Value: p})
+				}
+				pe = fieldpath.PathElement{Key: &keys}
+			}
+		}
+		out = append(out, pe)
+	}
+	return out
+}
+
+func valuePtr(v value.Value) *value.Value {
+	return &v
+}
+
+// RedactSet returns the set with every path redacted by RedactPath.
+func (r *PathRedactor) RedactSet(set *fieldpath.Set) *fieldpath.Set {
+	out := fieldpath.NewSet()
+	set.Iterate(func(p fieldpath.Path) {
+		out.Insert(r.RedactPath(p))
+	})
+	return out
+}
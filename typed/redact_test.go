@@ -0,0 +1,229 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+var redactParser = func() *typed.Parser {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: password
+      sensitive: true
+      type:
+        scalar: string
+    - name: pin
+      sensitive: true
+      type:
+        scalar: numeric
+    - name: tokens
+      sensitive: true
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: associative
+    - name: users
+      type:
+        list:
+          elementType:
+            namedType: user
+          elementRelationship: associative
+          keys:
+          - name
+    - name: accounts
+      sensitive: true
+      type:
+        list:
+          elementType:
+            namedType: user
+          elementRelationship: associative
+          keys:
+          - name
+    - name: credentials
+      type:
+        list:
+          elementType:
+            namedType: credential
+          elementRelationship: associative
+          keys:
+          - id
+- name: credential
+  map:
+    fields:
+    - name: id
+      sensitive: true
+      type:
+        scalar: string
+    - name: value
+      sensitive: true
+      type:
+        scalar: string
+- name: user
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: secret
+      sensitive: true
+      type:
+        scalar: string
+    - name: data
+      sensitive: true
+      type:
+        map:
+          elementType:
+            scalar: string
+`)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}()
+
+func TestRedact(t *testing.T) {
+	pt := redactParser.Type("type")
+	object, err := pt.FromYAML(`
+name: a
+password: hunter2
+pin: 1234
+tokens: [abc, def]
+users:
+- name: b
+  secret: s3cr3t
+  data:
+    key: value
+credentials:
+- id: c
+  value: v4lu3
+`)
+	if err != nil {
+		t.Fatalf("failed to parse object: %v", err)
+	}
+	expected, err := pt.FromYAML(`
+name: a
+password: <redacted>
+pin: 0
+tokens: []
+users:
+- name: b
+  secret: <redacted>
+  data: {}
+credentials:
+- id: c
+  value: <redacted>
+`)
+	if err != nil {
+		t.Fatalf("failed to parse expected object: %v", err)
+	}
+	redacted, err := object.Redact()
+	if err != nil {
+		t.Fatalf("failed to redact: %v", err)
+	}
+	if err := redacted.Validate(); err != nil {
+		t.Errorf("redacted object is invalid: %v", err)
+	}
+	if !value.Equals(redacted.AsValue(), expected.AsValue()) {
+		t.Errorf("expected:\n%v\ngot:\n%v", value.ToString(expected.AsValue()), value.ToString(redacted.AsValue()))
+	}
+}
+
+func TestComparisonRedacted(t *testing.T) {
+	pt := redactParser.Type("type")
+	lhs, err := pt.FromYAML(`
+name: a
+tokens: [abc]
+`)
+	if err != nil {
+		t.Fatalf("failed to parse object: %v", err)
+	}
+	rhs, err := pt.FromYAML(`
+name: b
+tokens: [def, ghi]
+users:
+- name: c
+  secret: s3cr3t
+accounts:
+- name: root
+`)
+	if err != nil {
+		t.Fatalf("failed to parse object: %v", err)
+	}
+	c, err := lhs.Compare(rhs)
+	if err != nil {
+		t.Fatalf("failed to compare: %v", err)
+	}
+	if !c.Added.Has(_P("tokens", _V("def"))) {
+		t.Errorf("expected the comparison itself to be unredacted, got %v", c.Added)
+	}
+	if s := c.String(); !strings.Contains(s, "def") {
+		t.Errorf("expected String to be unredacted, got:\n%v", s)
+	}
+	redacted := c.Redacted()
+	s := redacted.String()
+	for _, secret := range []string{"abc", "def", "ghi", "s3cr3t", "root"} {
+		if strings.Contains(s, secret) {
+			t.Errorf("expected %q to be redacted from:\n%v", secret, s)
+		}
+	}
+	if !redacted.Added.Has(_P("accounts", _KBF("name", "<redacted-1>"))) {
+		t.Errorf("expected the keys of sensitive lists to be redacted, got %v", redacted.Added)
+	}
+	if redacted.Added.Size() != c.Added.Size() || redacted.Removed.Size() != c.Removed.Size() {
+		t.Errorf("expected redacted items to stay distinct, got %v", redacted)
+	}
+	if !redacted.Added.Has(_P("users", _KBF("name", "c"), "secret")) {
+		t.Errorf("expected other keys to be kept, got %v", redacted.Added)
+	}
+	if !redacted.Modified.Has(_P("name")) {
+		t.Errorf("expected other fields to be kept, got %v", redacted.Modified)
+	}
+}
+
+func TestComparisonRedactedMismatchedPath(t *testing.T) {
+	pt := redactParser.Type("type")
+	tv, err := pt.FromYAML(`name: a`)
+	if err != nil {
+		t.Fatalf("failed to parse object: %v", err)
+	}
+	c, err := tv.Compare(tv)
+	if err != nil {
+		t.Fatalf("failed to compare: %v", err)
+	}
+	// A path that doesn't match the schema: "name" is a string, so the
+	// rest of the path can't be resolved, and must be kept as is.
+	c.Added.Insert(_P("name", "password", _V("x")))
+	c.Added.Insert(_P("pin", "tokens", _V("y")))
+	redacted := c.Redacted()
+	if !redacted.Added.Has(_P("name", "password", _V("x"))) {
+		t.Errorf("expected a path that doesn't match the schema to be kept, got %v", redacted.Added)
+	}
+	if !redacted.Added.Has(_P("pin", "tokens", _V("<redacted-1>"))) {
+		t.Errorf("expected items under a sensitive field to be redacted, got %v", redacted.Added)
+	}
+}
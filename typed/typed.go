@@ -26,19 +26,65 @@ import (
 	"sigs.k8s.io/structured-merge-diff/v4/value"
 )
 
+// ValidationOptions changes the behavior of validation. Options are bits
+// that can be combined, and the zero value is the default validation.
+type ValidationOptions int
+
+const (
+	// StrictFields reports every field that isn't declared in the Fields
+	// of its struct, rather than only the first one. Undeclared fields
+	// are errors either way, unless the struct sets an ElementType for
+	// them, whichever type it is. Without this option, validation stops
+	// at the first undeclared field of each struct, so a value with
+	// several misspelled fields only gets an error for one of them.
+	StrictFields ValidationOptions = 1 << iota
+	// UnknownFieldsAsWarnings reports undeclared fields like StrictFields,
+	// but as warnings that don't fail validation. The values created with
+	// this option don't have the undeclared fields, so that they can be
+	// used like any other value: see AsTypedWithWarnings.
+	UnknownFieldsAsWarnings
+	// ValidateUnions requires unions to be normalized: at most one of
+	// their fields is set, and it matches the discriminator if there is
+	// one. Unions aren't validated by default, neither by Validate nor by
+	// AsTyped and the parsers, since values are usually sent to
+	// NormalizeUnions, and so aren't normalized yet.
+	ValidateUnions
+)
+
 // AsTyped accepts a value and a type and returns a TypedValue. 'v' must have
 // type 'typeName' in the schema. An error is returned if the v doesn't conform
 // to the schema.
-func AsTyped(v value.Value, s *schema.Schema, typeRef schema.TypeRef) (*TypedValue, error) {
+func AsTyped(v value.Value, s *schema.Schema, typeRef schema.TypeRef, opts ...ValidationOptions) (*TypedValue, error) {
+	tv, _, err := AsTypedWithWarnings(v, s, typeRef, opts...)
+	return tv, err
+}
+
+// AsTypedWithWarnings is like AsTyped, but also returns the violations
+// that were turned into warnings by the given options. With
+// UnknownFieldsAsWarnings, the undeclared fields are left out of the
+// returned value.
+func AsTypedWithWarnings(v value.Value, s *schema.Schema, typeRef schema.TypeRef, opts ...ValidationOptions) (*TypedValue, ValidationErrors, error) {
 	tv := &TypedValue{
 		value:   v,
 		typeRef: typeRef,
 		schema:  s,
 	}
-	if err := tv.Validate(); err != nil {
-		return nil, err
+	warnings, err := tv.validate(validationOptionsOf(opts))
+	if err != nil {
+		return nil, nil, err
 	}
-	return tv, nil
+	if len(warnings) == 0 {
+		return tv, nil, nil
+	}
+	a := tv.accessor()
+	for _, w := range warnings {
+		out, err := a.remove(tv.value, tv.typeRef, w.FieldPath)
+		if err != nil {
+			return nil, nil, wrapErrorf(err, "failed to remove undeclared field: ").WithFieldPath(w.FieldPath)
+		}
+		tv.value = value.NewValueInterface(out)
+	}
+	return tv, warnings, nil
 }
 
 // AsTypeUnvalidated is just like AsTyped, but doesn't validate that the type
@@ -76,14 +122,57 @@ func (tv TypedValue) Schema() *schema.Schema {
 	return tv.schema
 }
 
-// Validate returns an error with a list of every spec violation.
-func (tv TypedValue) Validate() error {
+// Validate returns an error with a list of every spec violation. Unions
+// that aren't normalized are only violations with ValidateUnions.
+func (tv TypedValue) Validate(opts ...ValidationOptions) error {
+	_, err := tv.ValidateWithWarnings(opts...)
+	return err
+}
+
+// ValidateWithWarnings is like Validate, but also returns the violations
+// that were turned into warnings by the given options.
+func (tv TypedValue) ValidateWithWarnings(opts ...ValidationOptions) (warnings ValidationErrors, err error) {
+	return tv.validate(validationOptionsOf(opts))
+}
+
+// validationOptionsOf combines the given options.
+func validationOptionsOf(opts []ValidationOptions) ValidationOptions {
+	var options ValidationOptions
+	for _, opt := range opts {
+		options |= opt
+	}
+	return options
+}
+
+// validate implements ValidateWithWarnings.
+func (tv TypedValue) validate(options ValidationOptions) (warnings ValidationErrors, err error) {
 	w := tv.walker()
 	defer w.finished()
-	if errs := w.validate(nil); len(errs) != 0 {
-		return errs
+	if options&(StrictFields|UnknownFieldsAsWarnings) != 0 {
+		w.strictFields = true
+	}
+	if options&UnknownFieldsAsWarnings != 0 {
+		w.unknownFieldsAsWarnings = true
 	}
-	return nil
+	if options&ValidateUnions != 0 {
+		w.validateUnions = true
+	}
+	errs := w.validate(nil)
+	if w.unknownFieldsAsWarnings {
+		var remaining ValidationErrors
+		for _, e := range errs {
+			if e.Kind == UnknownFieldError {
+				warnings = append(warnings, e)
+			} else {
+				remaining = append(remaining, e)
+			}
+		}
+		errs = remaining
+	}
+	if len(errs) != 0 {
+		return warnings, errs
+	}
+	return warnings, nil
 }
 
 // ToFieldSet creates a set containing every leaf field and item mentioned, or
@@ -123,6 +212,8 @@ func (tv TypedValue) Compare(rhs *TypedValue) (c *Comparison, err error) {
 		Removed:  fieldpath.NewSet(),
 		Modified: fieldpath.NewSet(),
 		Added:    fieldpath.NewSet(),
+		schema:   tv.schema,
+		typeRef:  tv.typeRef,
 	}
 	_, err = merge(&tv, rhs, func(w *mergingWalker) {
 		if w.lhs == nil {
@@ -154,6 +245,92 @@ func (tv TypedValue) RemoveItems(items *fieldpath.Set) *TypedValue {
 	return &tv
 }
 
+// ExtractItems returns a value with only the provided list or map items
+// extracted from the value. Key fields of extracted list items are always
+// kept, so the result can be used as an applied configuration. An error is
+// returned if the items of a list can't be identified.
+func (tv TypedValue) ExtractItems(items *fieldpath.Set) (*TypedValue, error) {
+	v, errs := extractItemsWithSchema(value.NewFreelistAllocator(), tv.value, items, tv.schema, tv.typeRef)
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	tv.value = v
+	return &tv, nil
+}
+
+// Get returns the value found at the given path, and whether it was found.
+// Key and Value path elements are resolved using the schema of the
+// lists they apply to. An error is returned if the path doesn't conform
+// to the schema, e.g. if it has a field that isn't declared, or an index
+// into an associative list.
+func (tv TypedValue) Get(path fieldpath.Path) (value.Value, bool, error) {
+	v, _, ok, err := tv.accessor().get(tv.value, tv.typeRef, path)
+	if err != nil {
+		return nil, false, wrapErrorf(err, "").WithFieldPath(path)
+	}
+	return v, ok, nil
+}
+
+// Set returns a copy of tv where the value at the given path has been
+// replaced by v. Missing maps, structs and keyed list items along the path
+// are created. Validation errors are returned if the result doesn't conform
+// to the schema. tv itself is left untouched.
+func (tv TypedValue) Set(path fieldpath.Path, v value.Value) (*TypedValue, error) {
+	out, err := tv.accessor().set(tv.value, tv.typeRef, path, v)
+	if err != nil {
+		return nil, wrapErrorf(err, "").WithFieldPath(path)
+	}
+	return AsTyped(value.NewValueInterface(out), tv.schema, tv.typeRef)
+}
+
+// Delete returns a copy of tv where the value at the given path has been
+// removed. Deleting a path that doesn't exist is not an error. Validation
+// errors are returned if the result doesn't conform to the schema, e.g.
+// if a key field of a list item is deleted. tv itself is left untouched.
+func (tv TypedValue) Delete(path fieldpath.Path) (*TypedValue, error) {
+	if len(path) == 0 {
+		return tv.Empty(), nil
+	}
+	out, err := tv.accessor().remove(tv.value, tv.typeRef, path)
+	if err != nil {
+		return nil, wrapErrorf(err, "").WithFieldPath(path)
+	}
+	return AsTyped(value.NewValueInterface(out), tv.schema, tv.typeRef)
+}
+
+// Redact returns a copy of tv where the values of the fields marked as
+// sensitive in the schema are replaced by a placeholder of the same type:
+// RedactedValue for strings, zero for numbers, false for booleans, and
+// empty lists or maps for containers. The key fields of associative lists
+// identify their items and are never redacted: the list itself must be
+// marked as sensitive to hide them.
+func (tv TypedValue) Redact() (*TypedValue, error) {
+	out, err := tv.redact()
+	if err != nil {
+		return nil, err
+	}
+	return AsTypedUnvalidated(out, tv.schema, tv.typeRef), nil
+}
+
+// Canonicalize returns a copy of tv in a canonical form, so that values
+// that only differ in representation become identical:
+// - the fields of maps are in the order of the schema, followed by the
+// fields that the schema doesn't declare, sorted by name,
+// - items of associative lists and sets are sorted by key,
+// - numbers of numeric and untyped scalars are int64 when they can be
+// represented exactly, float64 otherwise,
+// - null fields declared by non-atomic maps are dropped.
+//
+// value.ToJSON and value.ToYAML keep the order of the fields, so the
+// canonical form of a value always serializes to the same document.
+func (tv TypedValue) Canonicalize() (*TypedValue, error) {
+	out, err := tv.accessor().canonicalize(tv.value, tv.typeRef)
+	if err != nil {
+		return nil, wrapErrorf(err, "")
+	}
+	return AsTypedUnvalidated(out, tv.schema, tv.typeRef), nil
+}
+
 // NormalizeUnions takes the new object and normalizes the union:
 // - If discriminator changed to non-nil, and a new field has been added
 // that doesn't match, an error is returned,
@@ -163,7 +340,15 @@ func (tv TypedValue) RemoveItems(items *fieldpath.Set) *TypedValue {
 // discriminated one will be cleared,
 // - Otherwise, If only one field is left, update discriminator to that value.
 //
-// Please note: union behavior isn't finalized yet and this is still experimental.
+// tv is the current object and new the object it is updated to, which
+// usually has unions that aren't normalized yet. The Updater normalizes
+// unions on every Update, but it can also be used on its own, for example:
+//
+//	live, _ := parser.Type("type").FromYAML(current)
+//	updated, _ := parser.Type("type").FromYAML(request)
+//	normalized, err := live.NormalizeUnions(updated)
+//	...
+//	err = normalized.Validate(ValidateUnions)
 func (tv TypedValue) NormalizeUnions(new *TypedValue) (*TypedValue, error) {
 	var errs ValidationErrors
 	var normalizeFn = func(w *mergingWalker) {
@@ -172,12 +357,12 @@ func (tv TypedValue) NormalizeUnions(new *TypedValue) (*TypedValue, error) {
 			w.out = &v
 		}
 		if err := normalizeUnions(w); err != nil {
-			errs = append(errs, errorf(err.Error())...)
+			errs = append(errs, kindErrorf(UnionViolationError, "%v", err).WithFieldPath(w.path)...)
 		}
 	}
 	out, mergeErrs := merge(&tv, new, func(w *mergingWalker) {}, normalizeFn)
 	if mergeErrs != nil {
-		errs = append(errs, mergeErrs.(ValidationErrors)...)
+		errs = append(errs, wrapErrorf(mergeErrs, "")...)
 	}
 	if len(errs) > 0 {
 		return nil, errs
@@ -188,8 +373,8 @@ func (tv TypedValue) NormalizeUnions(new *TypedValue) (*TypedValue, error) {
 // NormalizeUnionsApply specifically normalize unions on apply. It
 // validates that the applied union is correct (there should be no
 // ambiguity there), and clear the fields according to the sent intent.
-//
-// Please note: union behavior isn't finalized yet and this is still experimental.
+// tv is the applied configuration, and new the result of merging it into
+// the current object.
 func (tv TypedValue) NormalizeUnionsApply(new *TypedValue) (*TypedValue, error) {
 	var errs ValidationErrors
 	var normalizeFn = func(w *mergingWalker) {
@@ -198,12 +383,12 @@ func (tv TypedValue) NormalizeUnionsApply(new *TypedValue) (*TypedValue, error)
 			w.out = &v
 		}
 		if err := normalizeUnionsApply(w); err != nil {
-			errs = append(errs, errorf(err.Error())...)
+			errs = append(errs, kindErrorf(UnionViolationError, "%v", err).WithFieldPath(w.path)...)
 		}
 	}
 	out, mergeErrs := merge(&tv, new, func(w *mergingWalker) {}, normalizeFn)
 	if mergeErrs != nil {
-		errs = append(errs, mergeErrs.(ValidationErrors)...)
+		errs = append(errs, wrapErrorf(mergeErrs, "")...)
 	}
 	if len(errs) > 0 {
 		return nil, errs
@@ -222,10 +407,10 @@ var mwPool = sync.Pool{
 
 func merge(lhs, rhs *TypedValue, rule, postRule mergeRule) (*TypedValue, error) {
 	if lhs.schema != rhs.schema {
-		return nil, errorf("expected objects with types from the same schema")
+		return nil, kindErrorf(SchemaError, "expected objects with types from the same schema")
 	}
 	if !lhs.typeRef.Equals(&rhs.typeRef) {
-		return nil, errorf("expected objects of the same type, but got %v and %v", lhs.typeRef, rhs.typeRef)
+		return nil, kindErrorf(SchemaError, "expected objects of the same type, but got %v and %v", lhs.typeRef, rhs.typeRef)
 	}
 
 	mw := mwPool.Get().(*mergingWalker)
@@ -279,6 +464,11 @@ type Comparison struct {
 	Modified *fieldpath.Set
 	// Added contains any fields added by rhs.
 	Added *fieldpath.Set
+
+	// The schema of the compared objects, used to redact sensitive
+	// fields. Comparisons built without one are never redacted.
+	schema  *schema.Schema
+	typeRef schema.TypeRef
 }
 
 // IsSame returns true if the comparison returned no changes (the two
@@ -287,7 +477,27 @@ func (c *Comparison) IsSame() bool {
 	return c.Removed.Empty() && c.Modified.Empty() && c.Added.Empty()
 }
 
-// String returns a human readable version of the comparison.
+// Redacted returns a copy of the comparison where the keys and set items
+// found in the paths of sensitive fields are replaced by numbered
+// placeholders, so that it can be shown where sensitive values must not
+// be. The same item has the same placeholder in the three sets.
+func (c *Comparison) Redacted() *Comparison {
+	if c.schema == nil {
+		return c
+	}
+	r := NewPathRedactor(c.schema, c.typeRef)
+	return &Comparison{
+		Removed:  r.RedactSet(c.Removed),
+		Modified: r.RedactSet(c.Modified),
+		Added:    r.RedactSet(c.Added),
+		schema:   c.schema,
+		typeRef:  c.typeRef,
+	}
+}
+
+// String returns a human readable version of the comparison. Sensitive
+// values are not redacted: use Redacted().String() for output that may
+// not show them.
 func (c *Comparison) String() string {
 	bld := strings.Builder{}
 	if !c.Modified.Empty() {
@@ -0,0 +1,268 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typedtest
+
+import (
+	"fmt"
+	"math/rand"
+
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// maxItems is the maximum number of items generated in a single list or
+// for the element type of a single map.
+const maxItems = 4
+
+// Generator generates random values that conform to a schema. The same
+// seed always generates the same values, in the same order.
+type Generator struct {
+	rand *rand.Rand
+	// MaxSize is the approximate number of scalars, lists and maps in the
+	// generated values. Key fields of list items are always generated,
+	// so the limit may be exceeded by a few values.
+	MaxSize int
+
+	// remaining is the size left for the value being generated.
+	remaining int
+}
+
+// NewGenerator returns a Generator seeded with seed that generates values
+// of about maxSize scalars and containers.
+func NewGenerator(seed int64, maxSize int) *Generator {
+	return &Generator{
+		rand:    rand.New(rand.NewSource(seed)),
+		MaxSize: maxSize,
+	}
+}
+
+// Generate returns a new random value of the given type. Items of
+// associative lists have unique keys, at most one field of each union is
+// set (along with the matching discriminator) and every field of a struct
+// is declared in its schema, unless the struct has an element type.
+func (g *Generator) Generate(pt typed.ParseableType) (*typed.TypedValue, error) {
+	g.remaining = g.MaxSize
+	v, err := g.value(pt.Schema, pt.TypeRef)
+	if err != nil {
+		return nil, err
+	}
+	return typed.AsTyped(value.NewValueInterface(v), pt.Schema, pt.TypeRef)
+}
+
+// value returns a random value of the given type.
+func (g *Generator) value(s *schema.Schema, tr schema.TypeRef) (interface{}, error) {
+	atom, ok := s.Resolve(tr)
+	if !ok {
+		return nil, fmt.Errorf("no type found matching: %v", tr)
+	}
+	g.remaining--
+
+	// Deduced types can be any of their atoms. Scalars are preferred once
+	// the size is exhausted, so that generation terminates.
+	var choices []func() (interface{}, error)
+	if atom.Scalar != nil {
+		choices = append(choices, func() (interface{}, error) { return g.scalar(*atom.Scalar), nil })
+	}
+	if atom.List != nil && (g.remaining > 0 || atom.Scalar == nil) {
+		choices = append(choices, func() (interface{}, error) { return g.list(s, atom.List) })
+	}
+	if atom.Map != nil && (g.remaining > 0 || atom.Scalar == nil) {
+		choices = append(choices, func() (interface{}, error) { return g.mapValue(s, atom.Map, nil) })
+	}
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("invalid atom: %v", tr)
+	}
+	return choices[g.rand.Intn(len(choices))]()
+}
+
+// scalar returns a random scalar of the given kind.
+func (g *Generator) scalar(kind schema.Scalar) interface{} {
+	switch kind {
+	case schema.Numeric:
+		if g.rand.Intn(4) == 0 {
+			return float64(g.rand.Intn(2000)-1000) / 8
+		}
+		return int64(g.rand.Intn(200) - 100)
+	case schema.String:
+		return g.string()
+	case schema.Boolean:
+		return g.rand.Intn(2) == 0
+	}
+	// Untyped scalars can be any of the above.
+	return g.scalar([]schema.Scalar{schema.Numeric, schema.String, schema.Boolean}[g.rand.Intn(3)])
+}
+
+// string returns a short random string.
+func (g *Generator) string() string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, 1+g.rand.Intn(6))
+	for i := range b {
+		b[i] = letters[g.rand.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// count returns a random number of items for a container, within the
+// remaining size.
+func (g *Generator) count() int {
+	if g.remaining <= 0 {
+		return 0
+	}
+	n := maxItems
+	if g.remaining < n {
+		n = g.remaining
+	}
+	return g.rand.Intn(n + 1)
+}
+
+// list returns a random list. The items of associative lists are unique:
+// by key if the list has keys, by value otherwise.
+func (g *Generator) list(s *schema.Schema, l *schema.List) (interface{}, error) {
+	n := g.count()
+	out := []interface{}{}
+	var seen []value.Value
+	// Duplicates are skipped, so try a few more times than needed.
+	for attempt := 0; len(out) < n && attempt < 3*n; attempt++ {
+		var item interface{}
+		var id value.Value
+		var err error
+		switch {
+		case l.ElementRelationship != schema.Associative:
+			item, err = g.value(s, l.ElementType)
+		case len(l.Keys) > 0:
+			item, id, err = g.keyedItem(s, l)
+		default:
+			item, err = g.setItem(s, l.ElementType)
+			id = value.NewValueInterface(item)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if id != nil && contains(seen, id) {
+			continue
+		}
+		seen = append(seen, id)
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func contains(values []value.Value, v value.Value) bool {
+	for _, seen := range values {
+		if value.Equals(seen, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyedItem returns a random item for a list with keys, and a value made
+// of its key fields.
+func (g *Generator) keyedItem(s *schema.Schema, l *schema.List) (interface{}, value.Value, error) {
+	atom, ok := s.Resolve(l.ElementType)
+	if !ok || atom.Map == nil {
+		return nil, nil, fmt.Errorf("associative list with keys has an element type that isn't a map: %v", l.ElementType)
+	}
+	g.remaining--
+	item, err := g.mapValue(s, atom.Map, l.Keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := map[string]interface{}{}
+	for _, k := range l.Keys {
+		keys[k] = item[k]
+	}
+	return item, value.NewValueInterface(keys), nil
+}
+
+// setItem returns a random scalar for a list without keys.
+func (g *Generator) setItem(s *schema.Schema, tr schema.TypeRef) (interface{}, error) {
+	atom, ok := s.Resolve(tr)
+	if !ok || atom.Scalar == nil {
+		return nil, fmt.Errorf("associative list without keys has an element type that isn't a scalar: %v", tr)
+	}
+	g.remaining--
+	return g.scalar(*atom.Scalar), nil
+}
+
+// mapValue returns a random map. Required fields are always set, other
+// fields only as long as there is some size left.
+func (g *Generator) mapValue(s *schema.Schema, m *schema.Map, required []string) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	isRequired := map[string]bool{}
+	for _, name := range required {
+		isRequired[name] = true
+	}
+
+	// Set at most one field of each union, and its discriminator.
+	skip := map[string]bool{}
+	for _, union := range m.Unions {
+		if union.Discriminator != nil {
+			skip[*union.Discriminator] = true
+		}
+		for _, f := range union.Fields {
+			skip[f.FieldName] = true
+		}
+		i := g.rand.Intn(len(union.Fields) + 1)
+		if i == len(union.Fields) || g.remaining <= 0 {
+			continue
+		}
+		chosen := union.Fields[i]
+		sf, ok := m.FindField(chosen.FieldName)
+		if !ok {
+			return nil, fmt.Errorf("union field %q isn't a field of the struct", chosen.FieldName)
+		}
+		v, err := g.value(s, sf.Type)
+		if err != nil {
+			return nil, err
+		}
+		out[chosen.FieldName] = v
+		if union.Discriminator != nil {
+			out[*union.Discriminator] = chosen.DiscriminatorValue
+		}
+	}
+
+	for _, sf := range m.Fields {
+		if skip[sf.Name] && !isRequired[sf.Name] {
+			continue
+		}
+		if !isRequired[sf.Name] && (g.remaining <= 0 || g.rand.Intn(2) == 0) {
+			continue
+		}
+		v, err := g.value(s, sf.Type)
+		if err != nil {
+			return nil, err
+		}
+		out[sf.Name] = v
+	}
+
+	if (m.ElementType != schema.TypeRef{}) {
+		for i := g.count(); i > 0; i-- {
+			name := g.string()
+			if _, ok := m.FindField(name); ok {
+				continue
+			}
+			v, err := g.value(s, m.ElementType)
+			if err != nil {
+				return nil, err
+			}
+			out[name] = v
+		}
+	}
+	return out, nil
+}
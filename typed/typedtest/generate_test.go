@@ -0,0 +1,197 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typedtest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/typed/typedtest"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+var parser = func() *typed.Parser {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: replicas
+      type:
+        scalar: numeric
+    - name: labels
+      type:
+        map:
+          elementType:
+            scalar: string
+    - name: atomic
+      type:
+        map:
+          elementType:
+            scalar: string
+          elementRelationship: atomic
+    - name: set
+      type:
+        list:
+          elementType:
+            scalar: numeric
+          elementRelationship: associative
+    - name: list
+      type:
+        list:
+          elementType:
+            namedType: item
+          elementRelationship: associative
+          keys:
+          - key
+          - id
+    - name: atomicList
+      type:
+        list:
+          elementType:
+            namedType: item
+          elementRelationship: atomic
+    - name: untyped
+      type:
+        namedType: __untyped_deduced_
+    - name: discriminator
+      type:
+        scalar: string
+    - name: one
+      type:
+        scalar: string
+    - name: two
+      type:
+        namedType: item
+    unions:
+    - discriminator: discriminator
+      fields:
+      - fieldName: one
+        discriminatorValue: One
+      - fieldName: two
+        discriminatorValue: Two
+- name: item
+  map:
+    fields:
+    - name: key
+      type:
+        scalar: string
+    - name: id
+      type:
+        scalar: boolean
+    - name: value
+      type:
+        namedType: type
+- name: __untyped_atomic_
+  scalar: untyped
+  list:
+    elementType:
+      namedType: __untyped_atomic_
+    elementRelationship: atomic
+  map:
+    elementType:
+      namedType: __untyped_atomic_
+    elementRelationship: atomic
+- name: __untyped_deduced_
+  scalar: untyped
+  list:
+    elementType:
+      namedType: __untyped_atomic_
+    elementRelationship: atomic
+  map:
+    elementType:
+      namedType: __untyped_deduced_
+    elementRelationship: separable
+`)
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}()
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	pt := parser.Type("type")
+	for seed := int64(0); seed < 20; seed++ {
+		lhs, err := typedtest.NewGenerator(seed, 50).Generate(pt)
+		if err != nil {
+			t.Fatalf("failed to generate value for seed %v: %v", seed, err)
+		}
+		rhs, err := typedtest.NewGenerator(seed, 50).Generate(pt)
+		if err != nil {
+			t.Fatalf("failed to generate value for seed %v: %v", seed, err)
+		}
+		if !value.Equals(lhs.AsValue(), rhs.AsValue()) {
+			t.Errorf("seed %v generated different values:\n%v\n%v", seed, value.ToString(lhs.AsValue()), value.ToString(rhs.AsValue()))
+		}
+	}
+}
+
+func TestGenerateSize(t *testing.T) {
+	pt := parser.Type("type")
+	g := typedtest.NewGenerator(0, 0)
+	for i := 0; i < 20; i++ {
+		tv, err := g.Generate(pt)
+		if err != nil {
+			t.Fatalf("failed to generate value: %v", err)
+		}
+		if !value.Equals(tv.AsValue(), value.NewValueInterface(map[string]interface{}{})) {
+			t.Errorf("expected an empty value, got %v", value.ToString(tv.AsValue()))
+		}
+	}
+}
+
+// Generated values are validated by Generate, so these tests check that
+// they satisfy a few properties of merges and comparisons.
+func TestMergeProperties(t *testing.T) {
+	for _, pt := range []typed.ParseableType{parser.Type("type"), typed.DeducedParseableType} {
+		g := typedtest.NewGenerator(42, 100)
+		for i := 0; i < 50; i++ {
+			lhs, err := g.Generate(pt)
+			if err != nil {
+				t.Fatalf("failed to generate value: %v", err)
+			}
+			rhs, err := g.Generate(pt)
+			if err != nil {
+				t.Fatalf("failed to generate value: %v", err)
+			}
+			t.Run(fmt.Sprintf("%v/%v", *pt.TypeRef.NamedType, i), func(t *testing.T) {
+				merged, err := lhs.Merge(lhs)
+				if err != nil {
+					t.Fatalf("failed to merge: %v", err)
+				}
+				if !value.Equals(merged.AsValue(), lhs.AsValue()) {
+					t.Errorf("merge isn't idempotent:\n%v\n%v", value.ToString(lhs.AsValue()), value.ToString(merged.AsValue()))
+				}
+
+				forward, err := lhs.Compare(rhs)
+				if err != nil {
+					t.Fatalf("failed to compare: %v", err)
+				}
+				backward, err := rhs.Compare(lhs)
+				if err != nil {
+					t.Fatalf("failed to compare: %v", err)
+				}
+				if !forward.Added.Equals(backward.Removed) || !forward.Removed.Equals(backward.Added) || !forward.Modified.Equals(backward.Modified) {
+					t.Errorf("compare isn't symmetric:\n%v\n%v", forward, backward)
+				}
+			})
+		}
+	}
+}
@@ -18,6 +18,7 @@ package typed
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"sigs.k8s.io/structured-merge-diff/v4/schema"
@@ -27,7 +28,7 @@ import (
 func normalizeUnions(w *mergingWalker) error {
 	atom, found := w.schema.Resolve(w.typeRef)
 	if !found {
-		panic(fmt.Sprintf("Unable to resolve schema in normalize union: %v/%v", w.schema, w.typeRef))
+		return fmt.Errorf("unable to resolve schema in normalize union: %v", w.typeRef)
 	}
 	// Unions can only be in structures, and the struct must not have been removed
 	if atom.Map == nil || w.out == nil {
@@ -38,8 +39,12 @@ func normalizeUnions(w *mergingWalker) error {
 	if w.lhs != nil && !w.lhs.IsNull() {
 		old = w.lhs.AsMap()
 	}
+	var new value.Map
+	if w.rhs != nil && !w.rhs.IsNull() {
+		new = w.rhs.AsMap()
+	}
 	for _, union := range atom.Map.Unions {
-		if err := newUnion(&union).Normalize(old, w.rhs.AsMap(), value.NewValueInterface(*w.out).AsMap()); err != nil {
+		if err := newUnion(&union).Normalize(old, new, value.NewValueInterface(*w.out).AsMap()); err != nil {
 			return err
 		}
 	}
@@ -49,7 +54,7 @@ func normalizeUnions(w *mergingWalker) error {
 func normalizeUnionsApply(w *mergingWalker) error {
 	atom, found := w.schema.Resolve(w.typeRef)
 	if !found {
-		panic(fmt.Sprintf("Unable to resolve schema in normalize union: %v/%v", w.schema, w.typeRef))
+		return fmt.Errorf("unable to resolve schema in normalize union: %v", w.typeRef)
 	}
 	// Unions can only be in structures, and the struct must not have been removed
 	if atom.Map == nil || w.out == nil {
@@ -60,10 +65,14 @@ func normalizeUnionsApply(w *mergingWalker) error {
 	if w.lhs != nil && !w.lhs.IsNull() {
 		old = w.lhs.AsMap()
 	}
+	var new value.Map
+	if w.rhs != nil && !w.rhs.IsNull() {
+		new = w.rhs.AsMap()
+	}
 
 	for _, union := range atom.Map.Unions {
 		out := value.NewValueInterface(*w.out)
-		if err := newUnion(&union).NormalizeApply(old, w.rhs.AsMap(), out.AsMap()); err != nil {
+		if err := newUnion(&union).NormalizeApply(old, new, out.AsMap()); err != nil {
 			return err
 		}
 		*w.out = out.Unstructured()
@@ -147,17 +156,16 @@ func newFieldsSet(m value.Map, fields []field) fieldsSet {
 }
 
 func (fs fieldsSet) Add(f field) {
-	if fs == nil {
-		fs = map[field]struct{}{}
-	}
 	fs[f] = struct{}{}
 }
 
+// One returns the first of the fields, in alphabetical order.
 func (fs fieldsSet) One() *field {
-	for f := range fs {
-		return &f
+	if len(fs) == 0 {
+		return nil
 	}
-	return nil
+	f := fs.List()[0]
+	return &f
 }
 
 func (fs fieldsSet) Has(f field) bool {
@@ -165,11 +173,13 @@ func (fs fieldsSet) Has(f field) bool {
 	return ok
 }
 
+// List returns the fields in alphabetical order.
 func (fs fieldsSet) List() []field {
 	fields := []field{}
 	for f := range fs {
 		fields = append(fields, f)
 	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i] < fields[j] })
 	return fields
 }
 
@@ -185,7 +195,7 @@ func (fs fieldsSet) Difference(o fieldsSet) fieldsSet {
 
 func (fs fieldsSet) String() string {
 	s := []string{}
-	for k := range fs {
+	for _, k := range fs.List() {
 		s = append(s, string(k))
 	}
 	return strings.Join(s, ", ")
@@ -213,6 +223,42 @@ func newUnion(su *schema.Union) *union {
 	return u
 }
 
+// name describes the union in errors.
+func (u *union) name() string {
+	if u.d != nil {
+		return fmt.Sprintf("union with discriminator %q", u.d.name)
+	}
+	fields := fieldsSet{}
+	for _, f := range u.f {
+		fields.Add(f)
+	}
+	return fmt.Sprintf("union of fields %v", fields)
+}
+
+// Validate returns an error if the union isn't normalized: at most one
+// field can be set, and it must match the discriminator if there is one.
+func (u *union) Validate(m value.Map) error {
+	set := newFieldsSet(m, u.f)
+	if len(set) > 1 {
+		return fmt.Errorf("%v: fields %v are set, but at most one can be", u.name(), set)
+	}
+	if d := u.d.Get(m); d != "" && len(set) == 1 && u.dn.toField(d) != *set.One() {
+		return fmt.Errorf("%v: discriminator %q doesn't match field %v", u.name(), d, *set.One())
+	}
+	return nil
+}
+
+// validateUnions validates every union of a struct.
+func validateUnions(t *schema.Map, m value.Map) ValidationErrors {
+	var errs ValidationErrors
+	for i := range t.Unions {
+		if err := newUnion(&t.Unions[i]).Validate(m); err != nil {
+			errs = append(errs, kindError(UnionViolationError, "%v", err))
+		}
+	}
+	return errs
+}
+
 // clear removes all the fields in map that are part of the union, but
 // the one we decided to keep.
 func (u *union) clear(m value.Map, f field) {
@@ -230,17 +276,17 @@ func (u *union) Normalize(old, new, out value.Map) error {
 
 	if u.d.Get(old) != u.d.Get(new) && u.d.Get(new) != "" {
 		if len(diff) == 1 && u.d.Get(new) != u.dn.toDiscriminated(*diff.One()) {
-			return fmt.Errorf("discriminator (%v) and field changed (%v) don't match", u.d.Get(new), diff.One())
+			return fmt.Errorf("%v: discriminator (%v) and field changed (%v) don't match", u.name(), u.d.Get(new), *diff.One())
 		}
 		if len(diff) > 1 {
-			return fmt.Errorf("multiple new fields added: %v", diff)
+			return fmt.Errorf("%v: multiple new fields added: %v", u.name(), diff)
 		}
 		u.clear(out, u.dn.toField(u.d.Get(new)))
 		return nil
 	}
 
 	if len(ns) > 1 {
-		return fmt.Errorf("multiple fields set without discriminator change: %v", ns)
+		return fmt.Errorf("%v: multiple fields set without discriminator change: %v", u.name(), ns)
 	}
 
 	// Set discriminiator if it needs to be deduced.
@@ -254,7 +300,7 @@ func (u *union) Normalize(old, new, out value.Map) error {
 func (u *union) NormalizeApply(applied, merged, out value.Map) error {
 	as := newFieldsSet(applied, u.f)
 	if len(as) > 1 {
-		return fmt.Errorf("more than one field of union applied: %v", as)
+		return fmt.Errorf("%v: more than one field of union applied: %v", u.name(), as)
 	}
 	if len(as) == 0 {
 		// None is set, just leave.
@@ -262,7 +308,7 @@ func (u *union) NormalizeApply(applied, merged, out value.Map) error {
 	}
 	// We have exactly one, discriminiator must match if set
 	if u.d.Get(applied) != "" && u.d.Get(applied) != u.dn.toDiscriminated(*as.One()) {
-		return fmt.Errorf("applied discriminator (%v) doesn't match applied field (%v)", u.d.Get(applied), *as.One())
+		return fmt.Errorf("%v: applied discriminator (%v) doesn't match applied field (%v)", u.name(), u.d.Get(applied), *as.One())
 	}
 
 	// Update discriminiator if needed
@@ -324,3 +324,82 @@ func TestNormalizeUnionError(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateUnions(t *testing.T) {
+	tests := []struct {
+		name   string
+		object typed.YAMLObject
+		errors []string
+	}{
+		{
+			name:   "empty",
+			object: `{}`,
+		},
+		{
+			name:   "one field with discriminator",
+			object: `{"one": 1, "discriminator": "One", "b": 1, "letter": "b"}`,
+		},
+		{
+			name:   "one field without discriminator",
+			object: `{"two": 1}`,
+		},
+		{
+			name:   "two fields set",
+			object: `{"one": 1, "two": 2}`,
+			errors: []string{`union with discriminator "discriminator": fields one, two are set, but at most one can be`},
+		},
+		{
+			name:   "discriminator doesn't match",
+			object: `{"a": 1, "letter": "b"}`,
+			errors: []string{`union with discriminator "letter": discriminator "b" doesn't match field a`},
+		},
+		{
+			name:   "both unions invalid",
+			object: `{"one": 1, "three": 3, "a": 1, "letter": "b"}`,
+			errors: []string{
+				`union with discriminator "discriminator": fields one, three are set, but at most one can be`,
+				`union with discriminator "letter": discriminator "b" doesn't match field a`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Neither parsing nor Validate validate unions unless
+			// asked to, since they are usually normalized later on.
+			tv, err := unionParser.FromYAML(test.object)
+			if err != nil {
+				t.Fatalf("failed to parse object: %v", err)
+			}
+			if err := tv.Validate(); err != nil {
+				t.Fatalf("unions shouldn't be validated by default: %v", err)
+			}
+			_, parseErr := unionParser.FromYAML(test.object, typed.ValidateUnions)
+			err = tv.Validate(typed.ValidateUnions)
+			if len(test.errors) == 0 {
+				if err != nil || parseErr != nil {
+					t.Fatalf("unexpected error: %v, %v", err, parseErr)
+				}
+				return
+			}
+			if parseErr == nil {
+				t.Errorf("expected parsing with ValidateUnions to fail")
+			}
+			errs, ok := err.(typed.ValidationErrors)
+			if !ok {
+				t.Fatalf("expected ValidationErrors, got %v", err)
+			}
+			if len(errs) != len(test.errors) {
+				t.Fatalf("expected %d errors, got: %v", len(test.errors), errs)
+			}
+			for i, e := range errs {
+				if e.Kind != typed.UnionViolationError {
+					t.Errorf("expected kind %v, got %v", typed.UnionViolationError, e.Kind)
+				}
+				if e.ErrorMessage != test.errors[i] {
+					t.Errorf("expected error %q, got %q", test.errors[i], e.ErrorMessage)
+				}
+			}
+		})
+	}
+}
@@ -42,6 +42,9 @@ func (tv TypedValue) walker() *validatingObjectWalker {
 func (v *validatingObjectWalker) finished() {
 	v.schema = nil
 	v.typeRef = schema.TypeRef{}
+	v.strictFields = false
+	v.unknownFieldsAsWarnings = false
+	v.validateUnions = false
 	vPool.Put(v)
 }
 
@@ -50,6 +53,14 @@ type validatingObjectWalker struct {
 	schema  *schema.Schema
 	typeRef schema.TypeRef
 
+	// If set, report all undeclared fields of a struct instead of only
+	// the first one.
+	strictFields bool
+	// If set, undeclared fields are reported as warnings by the caller.
+	unknownFieldsAsWarnings bool
+	// If set, unions must be normalized.
+	validateUnions bool
+
 	// Allocate only as many walkers as needed for the depth by storing them here.
 	spareWalkers *[]*validatingObjectWalker
 	allocator    value.Allocator
@@ -77,8 +88,12 @@ func (v *validatingObjectWalker) finishDescent(v2 *validatingObjectWalker) {
 	*v.spareWalkers = append(*v.spareWalkers, v2)
 }
 
-func (v *validatingObjectWalker) validate(prefixFn func() string) ValidationErrors {
-	return resolveSchema(v.schema, v.typeRef, v.value, v).WithLazyPrefix(prefixFn)
+func (v *validatingObjectWalker) validate(pe *fieldpath.PathElement) ValidationErrors {
+	errs := resolveSchema(v.schema, v.typeRef, v.value, v)
+	if pe != nil {
+		errs = errs.WithPathElementPrefix(*pe)
+	}
+	return errs
 }
 
 func validateScalar(t *schema.Scalar, v value.Value, prefix string) (errs ValidationErrors) {
@@ -92,15 +107,15 @@ func validateScalar(t *schema.Scalar, v value.Value, prefix string) (errs Valida
 	case schema.Numeric:
 		if !v.IsFloat() && !v.IsInt() {
 			// TODO: should the schema separate int and float?
-			return errorf("%vexpected numeric (int or float), got %T", prefix, v.Unstructured())
+			return ValidationErrors{typeMismatchError("numeric", v, "%vexpected numeric (int or float), got %T", prefix, v.Unstructured())}
 		}
 	case schema.String:
 		if !v.IsString() {
-			return errorf("%vexpected string, got %#v", prefix, v)
+			return ValidationErrors{typeMismatchError("string", v, "%vexpected string, got %#v", prefix, v)}
 		}
 	case schema.Boolean:
 		if !v.IsBool() {
-			return errorf("%vexpected boolean, got %v", prefix, v)
+			return ValidationErrors{typeMismatchError("boolean", v, "%vexpected boolean, got %v", prefix, v)}
 		}
 	}
 	return nil
@@ -125,20 +140,20 @@ func (v *validatingObjectWalker) visitListItems(t *schema.List, list value.List)
 			var err error
 			pe, err = listItemToPathElement(v.allocator, v.schema, t, i, child)
 			if err != nil {
-				errs = append(errs, errorf("element %v: %v", i, err.Error())...)
+				errs = append(errs, wrapErrorf(err, "element %v: ", i)...)
 				// If we can't construct the path element, we can't
 				// even report errors deeper in the schema, so bail on
 				// this element.
 				return
 			}
 			if observedKeys.Has(pe) {
-				errs = append(errs, errorf("duplicate entries for key %v", pe.String())...)
+				errs = append(errs, kindErrorf(DuplicateKeyError, "duplicate entries for key %v", pe.String())...)
 			}
 			observedKeys.Insert(pe)
 		}
 		v2 := v.prepareDescent(t.ElementType)
 		v2.value = child
-		errs = append(errs, v2.validate(pe.String)...)
+		errs = append(errs, v2.validate(&pe)...)
 		v.finishDescent(v2)
 	}
 	return errs
@@ -147,7 +162,7 @@ func (v *validatingObjectWalker) visitListItems(t *schema.List, list value.List)
 func (v *validatingObjectWalker) doList(t *schema.List) (errs ValidationErrors) {
 	list, err := listValue(v.allocator, v.value)
 	if err != nil {
-		return errorf(err.Error())
+		return wrapErrorf(err, "")
 	}
 
 	if list == nil {
@@ -167,13 +182,12 @@ func (v *validatingObjectWalker) visitMapItems(t *schema.Map, m value.Map) (errs
 		if sf, ok := t.FindField(key); ok {
 			tr = sf.Type
 		} else if (t.ElementType == schema.TypeRef{}) {
-			errs = append(errs, errorf("field not declared in schema").WithPrefix(pe.String())...)
-			return false
+			errs = append(errs, kindErrorf(UnknownFieldError, "field not declared in schema").WithPathElementPrefix(pe)...)
+			return v.strictFields
 		}
 		v2 := v.prepareDescent(tr)
 		v2.value = val
-		// Giving pe.String as a parameter actually increases the allocations.
-		errs = append(errs, v2.validate(func() string { return pe.String() })...)
+		errs = append(errs, v2.validate(&pe)...)
 		v.finishDescent(v2)
 		return true
 	})
@@ -183,13 +197,16 @@ func (v *validatingObjectWalker) visitMapItems(t *schema.Map, m value.Map) (errs
 func (v *validatingObjectWalker) doMap(t *schema.Map) (errs ValidationErrors) {
 	m, err := mapValue(v.allocator, v.value)
 	if err != nil {
-		return errorf(err.Error())
+		return wrapErrorf(err, "")
 	}
 	if m == nil {
 		return nil
 	}
 	defer v.allocator.Free(m)
 	errs = v.visitMapItems(t, m)
+	if v.validateUnions && len(t.Unions) > 0 {
+		errs = append(errs, validateUnions(t, m)...)
+	}
 
 	return errs
 }
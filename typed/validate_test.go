@@ -17,10 +17,12 @@ limitations under the License.
 package typed_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
 
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
 	"sigs.k8s.io/structured-merge-diff/v4/schema"
 	"sigs.k8s.io/structured-merge-diff/v4/typed"
 )
@@ -289,6 +291,262 @@ func TestSchemaSchema(t *testing.T) {
 	}
 }
 
+func TestValidationErrorDetails(t *testing.T) {
+	tests := []struct {
+		object   typed.YAMLObject
+		expected typed.ValidationError
+	}{{
+		object: `{"string":1}`,
+		expected: typed.ValidationError{
+			FieldPath:    _P("string"),
+			Kind:         typed.TypeMismatchError,
+			ExpectedType: "string",
+			ActualType:   "numeric",
+		},
+	}, {
+		object: `{"list":[{"key":"a","value":"b"},{"key":"a"}]}`,
+		expected: typed.ValidationError{
+			FieldPath: _P("list"),
+			Kind:      typed.DuplicateKeyError,
+		},
+	}, {
+		object: `{"list":[{"value":"b"}]}`,
+		expected: typed.ValidationError{
+			FieldPath: _P("list"),
+			Kind:      typed.MissingKeyError,
+		},
+	}, {
+		object: `{"list":[{"key":"a","value":true}]}`,
+		expected: typed.ValidationError{
+			FieldPath:    _P("list", _KBF("key", "a"), "value"),
+			Kind:         typed.TypeMismatchError,
+			ExpectedType: "string",
+			ActualType:   "boolean",
+		},
+	}, {
+		object: `{"set":["a",{"b":"c"}]}`,
+		expected: typed.ValidationError{
+			FieldPath:    _P("set"),
+			Kind:         typed.TypeMismatchError,
+			ExpectedType: "scalar",
+			ActualType:   "map",
+		},
+	}, {
+		object: `{"set":["a",true]}`,
+		expected: typed.ValidationError{
+			FieldPath:    _P("set", _V(true)),
+			Kind:         typed.TypeMismatchError,
+			ExpectedType: "string",
+			ActualType:   "boolean",
+		},
+	}, {
+		object: `{"unknown":1}`,
+		expected: typed.ValidationError{
+			FieldPath: _P("unknown"),
+			Kind:      typed.UnknownFieldError,
+		},
+	}}
+
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: string
+      type:
+        scalar: string
+    - name: set
+      type:
+        list:
+          elementType:
+            scalar: string
+          elementRelationship: associative
+    - name: list
+      type:
+        list:
+          elementType:
+            map:
+              fields:
+              - name: key
+                type:
+                  scalar: string
+              - name: value
+                type:
+                  scalar: string
+          elementRelationship: associative
+          keys:
+          - key
+`)
+	if err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	pt := parser.Type("type")
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.object), func(t *testing.T) {
+			_, err := pt.FromYAML(tt.object)
+			errs, ok := err.(typed.ValidationErrors)
+			if !ok || len(errs) != 1 {
+				t.Fatalf("expected a single validation error, got %v", err)
+			}
+			got := errs[0]
+			if !got.FieldPath.Equals(tt.expected.FieldPath) {
+				t.Errorf("expected path %v, got %v", tt.expected.FieldPath, got.FieldPath)
+			}
+			if got.Path != tt.expected.FieldPath.String() {
+				t.Errorf("expected textual path %q, got %q", tt.expected.FieldPath.String(), got.Path)
+			}
+			if got.Kind != tt.expected.Kind {
+				t.Errorf("expected kind %v, got %v", tt.expected.Kind, got.Kind)
+			}
+			if got.ExpectedType != tt.expected.ExpectedType || got.ActualType != tt.expected.ActualType {
+				t.Errorf("expected types %q/%q, got %q/%q", tt.expected.ExpectedType, tt.expected.ActualType, got.ExpectedType, got.ActualType)
+			}
+
+			data, err := json.Marshal(errs)
+			if err != nil {
+				t.Fatalf("failed to serialize errors: %v", err)
+			}
+			var decoded typed.ValidationErrors
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("failed to deserialize errors %s: %v", data, err)
+			}
+			if len(decoded) != 1 || !decoded[0].FieldPath.Equals(got.FieldPath) || decoded[0].Path != got.Path || decoded[0].Error() != got.Error() ||
+				decoded[0].Kind != got.Kind || decoded[0].ExpectedType != got.ExpectedType || decoded[0].ActualType != got.ActualType {
+				t.Errorf("errors didn't round-trip through %s: got %#v", data, decoded)
+			}
+		})
+	}
+}
+
+func TestValidationErrorsTextualPath(t *testing.T) {
+	newErrors := func() typed.ValidationErrors {
+		return typed.ValidationErrors{{ErrorMessage: "invalid"}}
+	}
+	tests := []struct {
+		errs     typed.ValidationErrors
+		expected string
+	}{
+		{errs: newErrors().WithPath(".a"), expected: ".a: invalid"},
+		{errs: newErrors().WithPrefix(".b").WithPrefix(".a"), expected: ".a.b: invalid"},
+		{errs: newErrors().WithLazyPrefix(func() string { return ".a" }), expected: ".a: invalid"},
+		{errs: newErrors().WithFieldPath(_P("b")).WithPrefix(".a"), expected: ".a.b: invalid"},
+		{errs: newErrors().WithPrefix(".b").WithPathElementPrefix(_P("a")[0]), expected: ".a.b: invalid"},
+		{errs: newErrors().WithPath(".a").WithFieldPath(_P("b")), expected: ".b: invalid"},
+		{errs: typed.ValidationErrors{{Path: ".b", ErrorMessage: "invalid"}}.WithPrefix(".a"), expected: ".a.b: invalid"},
+		{errs: typed.ValidationErrors{{Path: ".b", ErrorMessage: "invalid"}}.WithPathElementPrefix(_P("a")[0]), expected: ".a.b: invalid"},
+	}
+	for _, tt := range tests {
+		if got := tt.errs.Error(); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+		data, err := json.Marshal(tt.errs)
+		if err != nil {
+			t.Fatalf("failed to serialize errors: %v", err)
+		}
+		var decoded typed.ValidationErrors
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("failed to deserialize errors %s: %v", data, err)
+		}
+		if got := decoded.Error(); got != tt.expected {
+			t.Errorf("expected %q after a round-trip through %s, got %q", tt.expected, data, got)
+		}
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	parser, err := typed.NewParser(`types:
+- name: type
+  map:
+    fields:
+    - name: name
+      type:
+        scalar: string
+    - name: struct
+      type:
+        map:
+          fields:
+          - name: value
+            type:
+              scalar: string
+    - name: labels
+      type:
+        map:
+          elementType:
+            scalar: string
+`)
+	if err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	pt := parser.Type("type")
+	object := typed.YAMLObject(`{"name":"a","nmae":"b","struct":{"value":"c","vaule":"d"},"labels":{"e":"f"},"lables":{}}`)
+	unknown := []fieldpath.Path{_P("nmae"), _P("lables"), _P("struct", "vaule")}
+	contains := func(errs typed.ValidationErrors, p fieldpath.Path) bool {
+		for _, e := range errs {
+			if e.FieldPath.Equals(p) && e.Kind == typed.UnknownFieldError {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Undeclared fields are always rejected, but StrictFields reports all
+	// of them, rather than stopping at the first one.
+	_, err = pt.FromYAML(object)
+	if errs, ok := err.(typed.ValidationErrors); !ok || len(errs) == 0 || len(errs) >= len(unknown) {
+		t.Errorf("expected only some unknown fields to be rejected by default, got %v", err)
+	}
+
+	_, err = pt.FromYAML(object, typed.StrictFields)
+	errs, ok := err.(typed.ValidationErrors)
+	if !ok || len(errs) != len(unknown) {
+		t.Fatalf("expected %v errors, got %v", len(unknown), err)
+	}
+	for _, p := range unknown {
+		if !contains(errs, p) {
+			t.Errorf("expected an error for %v, got %v", p, errs)
+		}
+	}
+
+	tv, warnings, err := pt.FromYAMLWithWarnings(object, typed.UnknownFieldsAsWarnings)
+	if err != nil {
+		t.Fatalf("expected unknown fields to be warnings, got %v", err)
+	}
+	if len(warnings) != len(unknown) {
+		t.Fatalf("expected %v warnings, got %v", len(unknown), warnings)
+	}
+	for _, p := range unknown {
+		if !contains(warnings, p) {
+			t.Errorf("expected a warning for %v, got %v", p, warnings)
+		}
+	}
+	// The undeclared fields are left out, so the value can be used like
+	// any other.
+	expected, err := pt.FromYAML(`{"name":"a","struct":{"value":"c"},"labels":{"e":"f"}}`)
+	if err != nil {
+		t.Fatalf("failed to parse expected object: %v", err)
+	}
+	if comparison, err := tv.Compare(expected); err != nil {
+		t.Fatalf("failed to compare: %v", err)
+	} else if !comparison.IsSame() {
+		t.Errorf("unexpected value:\n%v", comparison)
+	}
+	if _, err := tv.Merge(expected); err != nil {
+		t.Errorf("failed to merge: %v", err)
+	}
+	if warnings, err := tv.ValidateWithWarnings(typed.UnknownFieldsAsWarnings); err != nil || len(warnings) != 0 {
+		t.Errorf("expected no warnings left, got %v, %v", warnings, err)
+	}
+
+	// Options are bits that can be combined.
+	if _, err := pt.FromYAML(object, typed.StrictFields|typed.ValidateUnions); err == nil {
+		t.Errorf("expected combined options to reject unknown fields")
+	}
+
+	if _, err := pt.FromYAML(`{"name":1,"nmae":"b"}`, typed.UnknownFieldsAsWarnings); err == nil {
+		t.Errorf("expected other errors to still be reported")
+	}
+}
+
 func BenchmarkValidateStructured(b *testing.B) {
 	type Primitives struct {
 		s string
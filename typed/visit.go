@@ -0,0 +1,214 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// VisitFunc is called by Walk for every value of a TypedValue, with the
+// path of the value and the atom describing its type. The children of
+// the value are only visited if it returns true.
+type VisitFunc func(path fieldpath.Path, atom schema.Atom, v value.Value) bool
+
+// PairVisitFunc is called by WalkPair for every path found in either of
+// the two values. lhs or rhs is nil if the path is missing from that side.
+// The children of the values are only visited if it returns true.
+type PairVisitFunc func(path fieldpath.Path, atom schema.Atom, lhs, rhs value.Value) bool
+
+// Walk calls fn for the value and, recursively, for every field of its
+// maps and every item of its lists, parents before children. Fields are
+// visited in alphabetical order, list items in the order of the list.
+// Null values are visited, but have no children.
+func (tv TypedValue) Walk(fn VisitFunc) error {
+	return tv.WalkPair(nil, func(path fieldpath.Path, atom schema.Atom, lhs, _ value.Value) bool {
+		return fn(path, atom, lhs)
+	})
+}
+
+// WalkPair walks tv and rhs in parallel, calling fn for every path present
+// in either of them. Map fields are paired by name, items of associative
+// lists by key, which must be unique, and items of other lists by index.
+// Both values must have the same type; a nil rhs is walked as a missing
+// value.
+func (tv TypedValue) WalkPair(rhs *TypedValue, fn PairVisitFunc) error {
+	var r value.Value
+	if rhs != nil {
+		if !tv.schema.Equals(rhs.schema) {
+			return kindErrorf(SchemaError, "expected objects with types from the same schema")
+		}
+		if !tv.typeRef.Equals(&rhs.typeRef) {
+			return kindErrorf(SchemaError, "expected objects of the same type, but got %v and %v", tv.typeRef, rhs.typeRef)
+		}
+		r = rhs.value
+	}
+	w := visitor{schema: tv.schema, fn: fn}
+	if errs := w.visit(fieldpath.Path{}, tv.typeRef, tv.value, r); len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+type visitor struct {
+	schema *schema.Schema
+	fn     PairVisitFunc
+}
+
+// childPath returns a new path, so that callbacks can keep the paths
+// they are given.
+func childPath(path fieldpath.Path, pe fieldpath.PathElement) fieldpath.Path {
+	return append(path[:len(path):len(path)], pe)
+}
+
+func (w *visitor) visit(path fieldpath.Path, tr schema.TypeRef, lhs, rhs value.Value) ValidationErrors {
+	atom, ok := w.schema.Resolve(tr)
+	if !ok {
+		return kindErrorf(SchemaError, "schema error: no type found matching: %v", *tr.NamedType).WithFieldPath(path)
+	}
+	v := lhs
+	if v == nil {
+		v = rhs
+	}
+	atom = deduceAtom(atom, v)
+	if !w.fn(path, atom, lhs, rhs) {
+		return nil
+	}
+	switch {
+	case atom.List != nil:
+		return w.visitList(path, atom.List, lhs, rhs)
+	case atom.Map != nil:
+		return w.visitMap(path, atom.Map, lhs, rhs)
+	}
+	return nil
+}
+
+// items returns the items of a list with their path elements.
+func (w *visitor) items(t *schema.List, v value.Value) ([]fieldpath.PathElement, []value.Value, error) {
+	if v == nil || v.IsNull() {
+		return nil, nil, nil
+	}
+	l, err := listValue(value.HeapAllocator, v)
+	if err != nil {
+		return nil, nil, err
+	}
+	pes := make([]fieldpath.PathElement, 0, l.Length())
+	items := make([]value.Value, 0, l.Length())
+	seen := fieldpath.MakePathElementSet(l.Length())
+	for i := 0; i < l.Length(); i++ {
+		item := l.At(i)
+		index := i
+		pe := fieldpath.PathElement{Index: &index}
+		if t.ElementRelationship == schema.Associative {
+			if pe, err = listItemToPathElement(value.HeapAllocator, w.schema, t, i, item); err != nil {
+				return nil, nil, err
+			}
+			// Items are paired by key, which must identify one item.
+			if seen.Has(pe) {
+				return nil, nil, fmt.Errorf("duplicate entries for key %v", pe.String())
+			}
+			seen.Insert(pe)
+		}
+		pes = append(pes, pe)
+		items = append(items, item)
+	}
+	return pes, items, nil
+}
+
+func (w *visitor) visitList(path fieldpath.Path, t *schema.List, lhs, rhs value.Value) ValidationErrors {
+	lpes, litems, err := w.items(t, lhs)
+	if err != nil {
+		return wrapErrorf(err, "").WithFieldPath(path)
+	}
+	rpes, ritems, err := w.items(t, rhs)
+	if err != nil {
+		return wrapErrorf(err, "").WithFieldPath(path)
+	}
+	observedRHS := fieldpath.MakePathElementValueMap(len(rpes))
+	for j, pe := range rpes {
+		observedRHS.Insert(pe, ritems[j])
+	}
+	observedLHS := fieldpath.MakePathElementSet(len(lpes))
+	var errs ValidationErrors
+	for i, pe := range lpes {
+		observedLHS.Insert(pe)
+		r, _ := observedRHS.Get(pe)
+		errs = append(errs, w.visit(childPath(path, pe), t.ElementType, litems[i], r)...)
+	}
+	for j, pe := range rpes {
+		if !observedLHS.Has(pe) {
+			errs = append(errs, w.visit(childPath(path, pe), t.ElementType, nil, ritems[j])...)
+		}
+	}
+	return errs
+}
+
+// fields returns the fields of a map.
+func fields(v value.Value) (map[string]value.Value, error) {
+	if v == nil || v.IsNull() {
+		return nil, nil
+	}
+	m, err := mapValue(value.HeapAllocator, v)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]value.Value, m.Length())
+	m.Iterate(func(k string, _ value.Value) bool {
+		// Iterate may reuse the value it passes, so get a value we can keep.
+		out[k], _ = m.Get(k)
+		return true
+	})
+	return out, nil
+}
+
+func (w *visitor) visitMap(path fieldpath.Path, t *schema.Map, lhs, rhs value.Value) ValidationErrors {
+	lfields, err := fields(lhs)
+	if err != nil {
+		return wrapErrorf(err, "").WithFieldPath(path)
+	}
+	rfields, err := fields(rhs)
+	if err != nil {
+		return wrapErrorf(err, "").WithFieldPath(path)
+	}
+	names := make([]string, 0, len(lfields)+len(rfields))
+	for name := range lfields {
+		names = append(names, name)
+	}
+	for name := range rfields {
+		if _, ok := lfields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var errs ValidationErrors
+	for _, name := range names {
+		name := name
+		pe := fieldpath.PathElement{FieldName: &name}
+		ft, err := fieldType(t, name)
+		if err != nil {
+			errs = append(errs, wrapErrorf(err, "").WithFieldPath(childPath(path, pe))...)
+			continue
+		}
+		errs = append(errs, w.visit(childPath(path, pe), ft, lfields[name], rfields[name])...)
+	}
+	return errs
+}
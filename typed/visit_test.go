@@ -0,0 +1,158 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package typed_test
+
+import (
+	"testing"
+
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+func TestWalk(t *testing.T) {
+	object, err := extractParser.Type("type").FromYAML(`
+name: a
+labels:
+  a: b
+atomic:
+  c: d
+set: [a]
+list:
+- key: a
+  id: 1
+  value: x
+`)
+	if err != nil {
+		t.Fatalf("failed to parse object: %v", err)
+	}
+
+	visited := fieldpath.NewSet()
+	scalars := fieldpath.NewSet()
+	err = object.Walk(func(path fieldpath.Path, atom schema.Atom, v value.Value) bool {
+		visited.Insert(path)
+		if atom.Scalar != nil {
+			scalars.Insert(path)
+		}
+		// Don't descend into labels.
+		return !path.Equals(_P("labels"))
+	})
+	if err != nil {
+		t.Fatalf("failed to walk: %v", err)
+	}
+	expected := _NS(
+		_P(),
+		_P("name"),
+		_P("labels"),
+		_P("atomic"),
+		_P("atomic", "c"),
+		_P("set"),
+		_P("set", _V("a")),
+		_P("list"),
+		_P("list", _KBF("key", "a", "id", 1)),
+		_P("list", _KBF("key", "a", "id", 1), "key"),
+		_P("list", _KBF("key", "a", "id", 1), "id"),
+		_P("list", _KBF("key", "a", "id", 1), "value"),
+	)
+	if !visited.Equals(expected) {
+		t.Errorf("expected to visit:\n%v\ngot:\n%v", expected, visited)
+	}
+	expectedScalars := _NS(
+		_P("name"),
+		_P("atomic", "c"),
+		_P("set", _V("a")),
+		_P("list", _KBF("key", "a", "id", 1), "key"),
+		_P("list", _KBF("key", "a", "id", 1), "id"),
+		_P("list", _KBF("key", "a", "id", 1), "value"),
+	)
+	if !scalars.Equals(expectedScalars) {
+		t.Errorf("expected scalars:\n%v\ngot:\n%v", expectedScalars, scalars)
+	}
+}
+
+func TestWalkPair(t *testing.T) {
+	pt := extractParser.Type("type")
+	lhs, err := pt.FromYAML(`
+name: a
+set: [a, b]
+list:
+- key: a
+  id: 1
+  value: x
+`)
+	if err != nil {
+		t.Fatalf("failed to parse object: %v", err)
+	}
+	rhs, err := pt.FromYAML(`
+name: b
+set: [b, c]
+list:
+- key: b
+  id: 2
+- key: a
+  id: 1
+  value: x
+`)
+	if err != nil {
+		t.Fatalf("failed to parse object: %v", err)
+	}
+
+	lhsOnly, rhsOnly, changed := fieldpath.NewSet(), fieldpath.NewSet(), fieldpath.NewSet()
+	err = lhs.WalkPair(rhs, func(path fieldpath.Path, atom schema.Atom, l, r value.Value) bool {
+		switch {
+		case r == nil:
+			lhsOnly.Insert(path)
+		case l == nil:
+			rhsOnly.Insert(path)
+		case atom.Scalar != nil && !value.Equals(l, r):
+			changed.Insert(path)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("failed to walk: %v", err)
+	}
+	if expected := _NS(_P("set", _V("a"))); !lhsOnly.Equals(expected) {
+		t.Errorf("expected lhs only:\n%v\ngot:\n%v", expected, lhsOnly)
+	}
+	expected := _NS(
+		_P("set", _V("c")),
+		_P("list", _KBF("key", "b", "id", 2)),
+		_P("list", _KBF("key", "b", "id", 2), "key"),
+		_P("list", _KBF("key", "b", "id", 2), "id"),
+	)
+	if !rhsOnly.Equals(expected) {
+		t.Errorf("expected rhs only:\n%v\ngot:\n%v", expected, rhsOnly)
+	}
+	if expected := _NS(_P("name")); !changed.Equals(expected) {
+		t.Errorf("expected changed:\n%v\ngot:\n%v", expected, changed)
+	}
+}
+
+func TestWalkDuplicateKeys(t *testing.T) {
+	pt := extractParser.Type("type")
+	object := typed.AsTypedUnvalidated(value.NewValueInterface(map[string]interface{}{
+		"list": []interface{}{
+			map[string]interface{}{"key": "a", "id": 1},
+			map[string]interface{}{"key": "a", "id": 1, "value": "x"},
+		},
+	}), pt.Schema, pt.TypeRef)
+	if err := object.Walk(func(fieldpath.Path, schema.Atom, value.Value) bool { return true }); err == nil {
+		t.Errorf("expected items with duplicate keys to be rejected")
+	}
+}
@@ -118,13 +118,22 @@ func ReadJSONIter(iter *jsoniter.Iterator) (Value, error) {
 	return NewValueInterface(v), nil
 }
 
-// WriteJSONStream writes a value into a JSON stream.
+// WriteJSONStream writes a value into a JSON stream. The fields of maps
+// are sorted, unless they were made with NewValueFields.
 func WriteJSONStream(v Value, stream *jsoniter.Stream) {
+	if isOrdered(v) {
+		writeOrderedJSONStream(v, stream)
+		return
+	}
 	stream.WriteVal(v.Unstructured())
 }
 
-// ToYAML marshals a value as YAML.
+// ToYAML marshals a value as YAML. The fields of maps are sorted, unless
+// they were made with NewValueFields.
 func ToYAML(v Value) ([]byte, error) {
+	if isOrdered(v) {
+		return yaml.Marshal(orderedYAML(v))
+	}
 	return yaml.Marshal(v.Unstructured())
 }
 
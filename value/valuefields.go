@@ -0,0 +1,324 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package value
+
+import (
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v2"
+)
+
+// NewValueFields returns a map Value made of the given fields, which keep
+// their order: they are iterated, and written by ToJSON and ToYAML, in
+// that order. Each field must have a different name.
+func NewValueFields(fields FieldList) Value {
+	out := make(FieldList, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, Field{Name: f.Name, Value: ownValue(f.Value)})
+	}
+	return &valueFields{fields: out}
+}
+
+// NewValueItems returns a list Value made of the given items. Unlike the
+// items of an unstructured list, they can be made with NewValueFields.
+func NewValueItems(items []Value) Value {
+	out := make(valueItems, 0, len(items))
+	for _, item := range items {
+		out = append(out, ownValue(item))
+	}
+	return out
+}
+
+// ownValue returns a value that isn't shared with the caller, unless it
+// keeps the order of its fields.
+func ownValue(v Value) Value {
+	if isOrdered(v) {
+		return v
+	}
+	return NewValueInterface(v.Unstructured())
+}
+
+// childUsing returns a child value, allocated with a unless it keeps the
+// order of its fields, so that it can be given back to a.
+func childUsing(a Allocator, v Value) Value {
+	if vu, ok := v.(*valueUnstructured); ok {
+		return a.allocValueUnstructured().reuse(vu.Value)
+	}
+	return v
+}
+
+type valueFields struct {
+	fields FieldList
+}
+
+func (v *valueFields) IsMap() bool    { return true }
+func (v *valueFields) IsList() bool   { return false }
+func (v *valueFields) IsBool() bool   { return false }
+func (v *valueFields) IsInt() bool    { return false }
+func (v *valueFields) IsFloat() bool  { return false }
+func (v *valueFields) IsString() bool { return false }
+func (v *valueFields) IsNull() bool   { return false }
+
+func (v *valueFields) AsMap() Map {
+	return (*mapFields)(&v.fields)
+}
+
+func (v *valueFields) AsMapUsing(Allocator) Map {
+	return v.AsMap()
+}
+
+func (v *valueFields) AsList() List               { panic("value is a map") }
+func (v *valueFields) AsListUsing(Allocator) List { panic("value is a map") }
+func (v *valueFields) AsBool() bool               { panic("value is a map") }
+func (v *valueFields) AsInt() int64               { panic("value is a map") }
+func (v *valueFields) AsFloat() float64           { panic("value is a map") }
+func (v *valueFields) AsString() string           { panic("value is a map") }
+
+func (v *valueFields) Unstructured() interface{} {
+	out := make(map[string]interface{}, len(v.fields))
+	for _, f := range v.fields {
+		out[f.Name] = f.Value.Unstructured()
+	}
+	return out
+}
+
+type mapFields FieldList
+
+func (m *mapFields) find(key string) int {
+	for i := range *m {
+		if (*m)[i].Name == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *mapFields) Set(key string, val Value) {
+	val = ownValue(val)
+	if i := m.find(key); i >= 0 {
+		(*m)[i].Value = val
+		return
+	}
+	*m = append(*m, Field{Name: key, Value: val})
+}
+
+func (m *mapFields) Get(key string) (Value, bool) {
+	if i := m.find(key); i >= 0 {
+		return (*m)[i].Value, true
+	}
+	return nil, false
+}
+
+func (m *mapFields) GetUsing(a Allocator, key string) (Value, bool) {
+	if i := m.find(key); i >= 0 {
+		return childUsing(a, (*m)[i].Value), true
+	}
+	return nil, false
+}
+
+func (m *mapFields) Has(key string) bool {
+	return m.find(key) >= 0
+}
+
+func (m *mapFields) Delete(key string) {
+	if i := m.find(key); i >= 0 {
+		*m = append((*m)[:i], (*m)[i+1:]...)
+	}
+}
+
+func (m *mapFields) Equals(other Map) bool {
+	return m.EqualsUsing(HeapAllocator, other)
+}
+
+func (m *mapFields) EqualsUsing(a Allocator, other Map) bool {
+	return MapEqualsUsing(a, m, other)
+}
+
+func (m *mapFields) Iterate(fn func(key string, value Value) bool) bool {
+	for _, f := range *m {
+		if !fn(f.Name, f.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *mapFields) IterateUsing(a Allocator, fn func(key string, value Value) bool) bool {
+	for _, f := range *m {
+		v := childUsing(a, f.Value)
+		ok := fn(f.Name, v)
+		a.Free(v)
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *mapFields) Length() int {
+	return len(*m)
+}
+
+func (m *mapFields) Empty() bool {
+	return len(*m) == 0
+}
+
+func (m *mapFields) Zip(other Map, order MapTraverseOrder, fn func(key string, lhs, rhs Value) bool) bool {
+	return m.ZipUsing(HeapAllocator, other, order, fn)
+}
+
+func (m *mapFields) ZipUsing(a Allocator, other Map, order MapTraverseOrder, fn func(key string, lhs, rhs Value) bool) bool {
+	return defaultMapZip(a, m, other, order, fn)
+}
+
+type valueItems []Value
+
+func (v valueItems) IsMap() bool    { return false }
+func (v valueItems) IsList() bool   { return true }
+func (v valueItems) IsBool() bool   { return false }
+func (v valueItems) IsInt() bool    { return false }
+func (v valueItems) IsFloat() bool  { return false }
+func (v valueItems) IsString() bool { return false }
+func (v valueItems) IsNull() bool   { return false }
+
+func (v valueItems) AsMap() Map                 { panic("value is a list") }
+func (v valueItems) AsMapUsing(Allocator) Map   { panic("value is a list") }
+func (v valueItems) AsList() List               { return listItems(v) }
+func (v valueItems) AsListUsing(Allocator) List { return listItems(v) }
+func (v valueItems) AsBool() bool               { panic("value is a list") }
+func (v valueItems) AsInt() int64               { panic("value is a list") }
+func (v valueItems) AsFloat() float64           { panic("value is a list") }
+func (v valueItems) AsString() string           { panic("value is a list") }
+
+func (v valueItems) Unstructured() interface{} {
+	out := make([]interface{}, 0, len(v))
+	for _, item := range v {
+		out = append(out, item.Unstructured())
+	}
+	return out
+}
+
+type listItems []Value
+
+func (l listItems) Length() int {
+	return len(l)
+}
+
+func (l listItems) At(i int) Value {
+	return l[i]
+}
+
+func (l listItems) AtUsing(a Allocator, i int) Value {
+	return childUsing(a, l[i])
+}
+
+func (l listItems) Equals(other List) bool {
+	return l.EqualsUsing(HeapAllocator, other)
+}
+
+func (l listItems) EqualsUsing(a Allocator, other List) bool {
+	return ListEqualsUsing(a, l, other)
+}
+
+func (l listItems) Range() ListRange {
+	return l.RangeUsing(HeapAllocator)
+}
+
+func (l listItems) RangeUsing(a Allocator) ListRange {
+	if len(l) == 0 {
+		return EmptyRange
+	}
+	return &listItemsRange{list: l, allocator: a, i: -1}
+}
+
+type listItemsRange struct {
+	list      listItems
+	allocator Allocator
+	i         int
+}
+
+func (r *listItemsRange) Next() bool {
+	r.i += 1
+	return r.i < len(r.list)
+}
+
+func (r *listItemsRange) Item() (index int, value Value) {
+	if r.i < 0 {
+		panic("Item() called before first calling Next()")
+	}
+	if r.i >= len(r.list) {
+		panic("Item() called on ListRange with no more items")
+	}
+	return r.i, childUsing(r.allocator, r.list[r.i])
+}
+
+// isOrdered returns true if v was made with NewValueFields or
+// NewValueItems, so that the order of its fields must be kept.
+func isOrdered(v Value) bool {
+	switch v.(type) {
+	case *valueFields, valueItems:
+		return true
+	}
+	return false
+}
+
+// writeOrderedJSONStream writes v into a JSON stream, keeping the order of
+// the fields of maps made with NewValueFields. Other maps are sorted.
+func writeOrderedJSONStream(v Value, stream *jsoniter.Stream) {
+	switch t := v.(type) {
+	case *valueFields:
+		stream.WriteObjectStart()
+		for i, f := range t.fields {
+			if i > 0 {
+				stream.WriteMore()
+			}
+			stream.WriteObjectField(f.Name)
+			writeOrderedJSONStream(f.Value, stream)
+		}
+		stream.WriteObjectEnd()
+	case valueItems:
+		stream.WriteArrayStart()
+		for i, item := range t {
+			if i > 0 {
+				stream.WriteMore()
+			}
+			writeOrderedJSONStream(item, stream)
+		}
+		stream.WriteArrayEnd()
+	default:
+		stream.WriteVal(v.Unstructured())
+	}
+}
+
+// orderedYAML returns v in a form that yaml.Marshal writes keeping the
+// order of the fields of maps made with NewValueFields.
+func orderedYAML(v Value) interface{} {
+	switch t := v.(type) {
+	case *valueFields:
+		out := make(yaml.MapSlice, 0, len(t.fields))
+		for _, f := range t.fields {
+			out = append(out, yaml.MapItem{Key: f.Name, Value: orderedYAML(f.Value)})
+		}
+		return out
+	case valueItems:
+		out := make([]interface{}, 0, len(t))
+		for _, item := range t {
+			out = append(out, orderedYAML(item))
+		}
+		return out
+	}
+	return v.Unstructured()
+}